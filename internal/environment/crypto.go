@@ -0,0 +1,140 @@
+// Package environment has a definition of Environment struct with parser.
+// This file contains decryption of encrypted configs. Configs are
+// encrypted with AES-256-GCM using a key from the local key file.
+// Decrypted content is kept in memory only and passed to the content
+// parser of the config format.
+package environment
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	log "github.com/Sirupsen/logrus"
+
+	opts "github.com/9seconds/guidedog/internal/options"
+)
+
+// encryptionKeySize is a size of AES-256 key in bytes.
+const encryptionKeySize = 32
+
+// encryptedConfigHeader is a magic header of encrypted configs.
+var encryptedConfigHeader = []byte("GUIDEDOG-AES256GCM\n")
+
+// ReadKeyFile reads the key from the key file. Key file has to contain 32
+// bytes of the key either as is, hex-encoded or base64-encoded.
+func ReadKeyFile(path string) ([]byte, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(content) == encryptionKeySize {
+		return content, nil
+	}
+
+	trimmed := string(bytes.TrimSpace(content))
+	if key, err := hex.DecodeString(trimmed); err == nil && len(key) == encryptionKeySize {
+		return key, nil
+	}
+	if key, err := base64.StdEncoding.DecodeString(trimmed); err == nil && len(key) == encryptionKeySize {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("Key file %s has to contain %d bytes key (raw, hex or base64)", path, encryptionKeySize)
+}
+
+// EncryptConfig encrypts the content of config with the given key.
+func EncryptConfig(key []byte, plaintext []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	encrypted := make([]byte, 0, len(encryptedConfigHeader)+len(nonce)+len(plaintext)+aead.Overhead())
+	encrypted = append(encrypted, encryptedConfigHeader...)
+	encrypted = append(encrypted, nonce...)
+
+	return aead.Seal(encrypted, nonce, plaintext, encryptedConfigHeader), nil
+}
+
+// DecryptConfig decrypts the content of config with the given key.
+func DecryptConfig(key []byte, encrypted []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.HasPrefix(encrypted, encryptedConfigHeader) {
+		return nil, fmt.Errorf("Config is not encrypted by guide-dog")
+	}
+	encrypted = encrypted[len(encryptedConfigHeader):]
+
+	if len(encrypted) < aead.NonceSize() {
+		return nil, fmt.Errorf("Encrypted config is truncated")
+	}
+	nonce, ciphertext := encrypted[:aead.NonceSize()], encrypted[aead.NonceSize():]
+
+	plaintext, err := aead.Open(nil, nonce, ciphertext, encryptedConfigHeader)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot decrypt config: wrong key or corrupted content")
+	}
+
+	return plaintext, nil
+}
+
+// newAEAD returns AES-256-GCM cipher for the given key.
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != encryptionKeySize {
+		return nil, fmt.Errorf("Key has to be %d bytes long", encryptionKeySize)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// getEncryptedParser returns a parser for encrypted configs of the given
+// format. Config is decrypted in memory and parsed by the content parser
+// of the format.
+func getEncryptedParser(configFormat opts.ConfigFormat) environmentParser {
+	parser := getContentParser(configFormat)
+
+	return func(filename string, options *opts.Options) (map[string]string, error) {
+		if parser == nil {
+			return nil, fmt.Errorf("Config format %s cannot be encrypted", configFormat)
+		}
+
+		return parseConfigFile(filename, options, func(content []byte, options *opts.Options) (map[string]string, error) {
+			key, err := ReadKeyFile(options.DecryptionKey)
+			if err != nil {
+				log.WithFields(log.Fields{
+					"keyFile": options.DecryptionKey,
+					"error":   err,
+				}).Error("Cannot read decryption key.")
+				return nil, err
+			}
+
+			plaintext, err := DecryptConfig(key, content)
+			if err != nil {
+				return nil, err
+			}
+
+			return parser(plaintext, options)
+		})
+	}
+}
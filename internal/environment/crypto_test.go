@@ -0,0 +1,103 @@
+package environment
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	assert "github.com/stretchr/testify/assert"
+
+	opts "github.com/9seconds/guidedog/internal/options"
+)
+
+const testEncryptionKey = "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+
+func createKeyFile(dir string, content string) string {
+	path := filepath.Join(dir, "key")
+	ioutil.WriteFile(path, []byte(content), os.FileMode(0600))
+
+	return path
+}
+
+func TestReadKeyFile(t *testing.T) {
+	tempDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(tempDir)
+
+	expected, _ := hex.DecodeString(testEncryptionKey)
+
+	key, err := ReadKeyFile(createKeyFile(tempDir, testEncryptionKey+"\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, key, expected)
+
+	key, err = ReadKeyFile(createKeyFile(tempDir, "AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8=\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, key, expected)
+
+	key, err = ReadKeyFile(createKeyFile(tempDir, string(expected)))
+	assert.Nil(t, err)
+	assert.Equal(t, key, expected)
+
+	_, err = ReadKeyFile(createKeyFile(tempDir, "short"))
+	assert.NotNil(t, err)
+
+	_, err = ReadKeyFile(filepath.Join(tempDir, "nothing"))
+	assert.NotNil(t, err)
+}
+
+func TestEncryptDecryptConfig(t *testing.T) {
+	key, _ := hex.DecodeString(testEncryptionKey)
+
+	encrypted, err := EncryptConfig(key, []byte(envJSON))
+	assert.Nil(t, err)
+	assert.NotContains(t, string(encrypted), "world")
+
+	decrypted, err := DecryptConfig(key, encrypted)
+	assert.Nil(t, err)
+	assert.Equal(t, string(decrypted), envJSON)
+}
+
+func TestDecryptConfigFail(t *testing.T) {
+	key, _ := hex.DecodeString(testEncryptionKey)
+	encrypted, _ := EncryptConfig(key, []byte(envJSON))
+
+	wrongKey := make([]byte, len(key))
+	_, err := DecryptConfig(wrongKey, encrypted)
+	assert.NotNil(t, err)
+
+	corrupted := append([]byte{}, encrypted...)
+	corrupted[len(corrupted)-1] ^= 0xff
+	_, err = DecryptConfig(key, corrupted)
+	assert.NotNil(t, err)
+
+	_, err = DecryptConfig(key, []byte(envJSON))
+	assert.NotNil(t, err)
+
+	_, err = DecryptConfig(key[:16], encrypted)
+	assert.NotNil(t, err)
+}
+
+func TestUpdateWithEncryptedConfig(t *testing.T) {
+	tempDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(tempDir)
+
+	key, _ := hex.DecodeString(testEncryptionKey)
+	encrypted, _ := EncryptConfig(key, []byte("HELLO=world\nexport SECRET='top'\n"))
+	path := filepath.Join(tempDir, "secrets.env.enc")
+	ioutil.WriteFile(path, encrypted, os.FileMode(0600))
+
+	options := createOptions()
+	options.ConfigSources = []opts.ConfigSource{{Encrypted: true, Format: opts.ConfigFormatDotEnv, Path: path}}
+	options.DecryptionKey = createKeyFile(tempDir, testEncryptionKey)
+
+	env, err := NewEnvironment(options)
+	assert.Nil(t, err)
+	assert.Equal(t, env.Variables()["HELLO"], "world")
+	assert.Equal(t, env.Variables()["SECRET"], "top")
+
+	options.DecryptionKey = createKeyFile(tempDir, "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff")
+	err = env.Update()
+	assert.NotNil(t, err)
+	assert.Equal(t, env.Variables()["HELLO"], "world")
+}
@@ -0,0 +1,155 @@
+// Package environment has a definition of Environment struct with parser.
+// This file contains a lexer for .env files. For the parser itself please
+// check parsers.go file.
+package environment
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// dotEnvExportPrefix is a prefix which is allowed to be placed before
+// variable definition (so .env file may be sourced by shell).
+const dotEnvExportPrefix = "export"
+
+// dotEnvName defines allowed names of variables in .env files.
+var dotEnvName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// dotEnvEscapes defines escape sequences supported in double-quoted
+// values.
+var dotEnvEscapes = map[byte]byte{
+	'n':  '\n',
+	'r':  '\r',
+	't':  '\t',
+	'"':  '"',
+	'\\': '\\',
+	'$':  '$',
+}
+
+// parseDotEnv parses the content of .env file. It supports KEY=value
+// pairs, single and double quoted values (double quoted ones may span
+// several lines and have escape sequences), comments, blank lines and
+// leading export keyword.
+func parseDotEnv(content string) (envs map[string]string, err error) {
+	envs = make(map[string]string)
+	lines := strings.Split(strings.Replace(content, "\r\n", "\n", -1), "\n")
+
+	for idx := 0; idx < len(lines); idx++ {
+		lineNumber := idx + 1
+		line := strings.TrimLeft(lines[idx], " \t")
+
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, dotEnvExportPrefix+" ") || strings.HasPrefix(line, dotEnvExportPrefix+"\t") {
+			line = strings.TrimSpace(line[len(dotEnvExportPrefix):])
+		}
+
+		split := strings.SplitN(line, "=", 2)
+		if len(split) != 2 {
+			return nil, fmt.Errorf("Line %d: cannot find '=' in %s", lineNumber, line)
+		}
+
+		name := strings.TrimSpace(split[0])
+		if !dotEnvName.MatchString(name) {
+			return nil, fmt.Errorf("Line %d: incorrect variable name %s", lineNumber, name)
+		}
+
+		value := strings.TrimLeft(split[1], " \t")
+		var tail string
+
+		switch {
+		case strings.HasPrefix(value, `"`):
+			value, tail, idx, err = readDoubleQuotedValue(lines, idx, value[1:])
+		case strings.HasPrefix(value, "'"):
+			value, tail, err = readSingleQuotedValue(value[1:])
+		default:
+			value = readUnquotedValue(value)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Line %d: %v", lineNumber, err)
+		}
+
+		tail = strings.TrimSpace(tail)
+		if tail != "" && !strings.HasPrefix(tail, "#") {
+			return nil, fmt.Errorf("Line %d: unexpected characters after quoted value: %s", lineNumber, tail)
+		}
+
+		envs[name] = value
+	}
+
+	return
+}
+
+// readUnquotedValue returns a value without trailing comment.
+func readUnquotedValue(value string) string {
+	if strings.HasPrefix(value, "#") {
+		return ""
+	}
+
+	for idx := 1; idx < len(value); idx++ {
+		if value[idx] == '#' && (value[idx-1] == ' ' || value[idx-1] == '\t') {
+			value = value[:idx]
+			break
+		}
+	}
+
+	return strings.TrimSpace(value)
+}
+
+// readSingleQuotedValue returns a literal value till the closing quote and
+// the rest of the line.
+func readSingleQuotedValue(value string) (string, string, error) {
+	end := strings.Index(value, "'")
+	if end < 0 {
+		return "", "", fmt.Errorf("unterminated single-quoted value")
+	}
+
+	return value[:end], value[end+1:], nil
+}
+
+// readDoubleQuotedValue reads double quoted value which may span several
+// lines. It returns the value, the rest of the line after closing quote and
+// the index of the line where value is finished.
+func readDoubleQuotedValue(lines []string, idx int, value string) (string, string, int, error) {
+	var buffer bytes.Buffer
+
+	for {
+		joinLines := false
+
+		for pos := 0; pos < len(value); pos++ {
+			char := value[pos]
+
+			switch {
+			case char == '"':
+				return buffer.String(), value[pos+1:], idx, nil
+			case char == '\\' && pos+1 < len(value):
+				pos++
+				if escaped, ok := dotEnvEscapes[value[pos]]; ok {
+					buffer.WriteByte(escaped)
+				} else {
+					buffer.WriteByte(char)
+					buffer.WriteByte(value[pos])
+				}
+			case char == '\\':
+				// Trailing backslash joins lines.
+				joinLines = true
+			default:
+				buffer.WriteByte(char)
+			}
+		}
+
+		if !joinLines {
+			buffer.WriteByte('\n')
+		}
+
+		idx++
+		if idx >= len(lines) {
+			return "", "", idx, fmt.Errorf("unterminated double-quoted value")
+		}
+		value = lines[idx]
+	}
+}
@@ -5,103 +5,332 @@ package environment
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
+	"sort"
+	"strings"
+	"sync"
 
 	log "github.com/Sirupsen/logrus"
 
 	opts "github.com/9seconds/guidedog/internal/options"
 )
 
+// unsetValue is a value which marks variables to be removed from the
+// environment (e.g. by empty files in envdirs). Environment variables
+// cannot contain NUL bytes so this value is never set by configs.
+const unsetValue = "\x00"
+
+// Origin* consts family defines origins of variables which are not taken
+// from config sources.
+const (
+	OriginInherited     = "inherited"
+	OriginPredefined    = "env"
+	OriginSecretFile    = "secret-file"
+	OriginSchemaDefault = "schema-default"
+)
+
 // Environment is just a thin container on opts.Options which can parse
 // environment variables.
 type Environment struct {
-	Options         *opts.Options
-	parser          environmentParser
-	previousUpdates map[string]string
+	Options     *opts.Options
+	inherited   map[string]string
+	lock        *sync.RWMutex
+	snapshot    map[string]string
+	origins     map[string]string
+	secretPaths []string
 }
 
 func (env *Environment) String() string {
 	return fmt.Sprintf("%+v", *env)
 }
 
-// Parse does parsing of the config file according to its ConfigFormat.
-// Returns tuple of map with environment variables (key is the name, value
-// is a, umm, value). Error defines the error.
-func (env *Environment) Parse() (variables map[string]string, err error) {
-	if env.Options.ConfigPath == "" {
+// Parse does parsing of the config sources according to their
+// ConfigFormats. Sources are merged in the given order so later ones
+// override earlier. Returns tuple of map with environment variables (key is
+// the name, value is a, umm, value). Error defines the error.
+func (env *Environment) Parse() (map[string]string, error) {
+	variables, _, err := env.parse()
+
+	return variables, err
+}
+
+// parse does the same as Parse but returns origins of variables also:
+// config sources they are taken from.
+func (env *Environment) parse() (variables map[string]string, origins map[string]string, err error) {
+	variables = make(map[string]string)
+	origins = make(map[string]string)
+
+	if len(env.Options.ConfigSources) == 0 {
 		log.Info("Config path is not set, nothing to update.")
 		return
 	}
 
-	variables, err = env.parser(env.Options.ConfigPath)
-	if err != nil {
-		log.WithFields(log.Fields{
-			"configPath": env.Options.ConfigPath,
-			"error":      err,
-		}).Warn("Cannot parse")
-	} else {
-		log.WithField("variables", variables).Info("Parsed environment variables.")
+	for _, source := range env.Options.ConfigSources {
+		parser := getParser(source.Format)
+		switch {
+		case source.Encrypted:
+			parser = getEncryptedParser(source.Format)
+		case source.Exec:
+			parser = getExecParser(source.Format)
+		}
+
+		parsed, err := parser(source.Path, env.Options)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"configSource": source,
+				"error":        err,
+			}).Warn("Cannot parse")
+			return nil, nil, err
+		}
+
+		for name, value := range parsed {
+			variables[name] = value
+			origins[name] = source.String()
+		}
+	}
+
+	if env.Options.Interpolate {
+		variables, err = interpolate(variables, env.Options.Envs, env.inheritedVariables())
+		if err != nil {
+			return nil, nil, err
+		}
 	}
+	log.WithField("names", sortedNames(variables)).Info("Parsed environment variables.")
 
 	return
 }
 
-// Update does update of stored environment variables set with retrieved
-// data from Parse output and maintains the set of environment variables
-// of current process (which are derived by executed commands).
+// Update does update of stored environment snapshot with retrieved data
+// from Parse output. Snapshot consists of inherited environment variables
+// (filtered according to the inheritance rules), variables from configs and
+// explicitly preset ones. If secret files are enabled, *_FILE variables
+// from configs and preset ones are resolved. Snapshot is validated against the schema and
+// replaced atomically only if parsing and validation succeed. Environment
+// of current process is never changed.
 func (env *Environment) Update() (err error) {
-	if env.Options.ConfigFormat == opts.ConfigFormatNone {
+	variables, origins, err := env.parse()
+	if err != nil {
 		return
 	}
 
-	variables, err := env.Parse()
-	if err != nil {
-		return
+	// Maintaines the list of explicitly preset environment variables.
+	// Sets them forcefully, overrides previously set values.
+	for name, value := range env.Options.Envs {
+		log.WithField("name", name).Debug("Set predefined environment variable.")
+		variables[name] = value
+		origins[name] = OriginPredefined
+	}
+
+	snapshot := env.inheritedVariables()
+	snapshotOrigins := make(map[string]string, len(snapshot))
+	for name := range snapshot {
+		snapshotOrigins[name] = OriginInherited
 	}
 
 	// Sets environment variables.
 	for name, value := range variables {
-		log.WithFields(log.Fields{
-			"name":  name,
-			"value": value,
-		}).Debug("Set environment variable.")
-
-		env.previousUpdates[name] = value
-		os.Setenv(name, value)
-	}
-
-	// Maintains the list of previously set environment variables. Removes
-	// obsoletes.
-	for name := range env.previousUpdates {
-		if _, ok := variables[name]; !ok {
-			log.WithField("name", name).Debug("Delete environment variable.")
-			delete(env.previousUpdates, name)
-			os.Unsetenv(name)
+		if value == unsetValue {
+			log.WithField("name", name).Debug("Remove environment variable.")
+			delete(snapshot, name)
+			delete(snapshotOrigins, name)
+			continue
 		}
+
+		log.WithField("name", name).Debug("Set environment variable.")
+		snapshot[name] = value
+		snapshotOrigins[name] = origins[name]
 	}
 
-	// Maintaines the list of explicitly preset environment variables.
-	// Sets them forcefully, overrides previously set values.
-	for name, value := range env.Options.Envs {
-		log.WithFields(log.Fields{
-			"name":  name,
-			"value": value,
-		}).Debug("Set predefined environment variable.")
-		os.Setenv(name, value)
+	var secretPaths []string
+	if env.Options.SecretFiles.Enabled {
+		secretPaths = resolveSecretFiles(snapshot, snapshotOrigins, env.Options.SecretFiles.DropFileVariables)
+	}
+
+	if err = validateEnvironment(snapshot, snapshotOrigins, env.Options.Schema); err != nil {
+		log.WithField("error", err).Error("Environment does not match schema.")
+		return
+	}
+
+	env.lock.Lock()
+	env.snapshot = snapshot
+	env.origins = snapshotOrigins
+	env.secretPaths = secretPaths
+	env.lock.Unlock()
+
+	return
+}
+
+// inheritedVariables returns inherited environment variables filtered
+// according to the inheritance rules.
+func (env *Environment) inheritedVariables() map[string]string {
+	variables := make(map[string]string)
+	for name, value := range env.inherited {
+		if env.Options.Inheritance.Inherits(name) {
+			variables[name] = value
+		}
+	}
+
+	return variables
+}
+
+// TrackedPaths returns the list of paths which affect environment: config
+// sources (except of commands), nested directories of recursive envdirs
+// and files with secrets.
+func (env *Environment) TrackedPaths() []string {
+	env.lock.RLock()
+	defer env.lock.RUnlock()
+
+	paths := make([]string, 0, len(env.Options.ConfigSources)+len(env.secretPaths))
+	for _, source := range env.Options.ConfigSources {
+		if source.Exec {
+			continue
+		}
+
+		paths = append(paths, source.Path)
+		if source.Format == opts.ConfigFormatEnvDir && env.Options.EnvDir.Recursive {
+			paths = append(paths, listEnvDirs(source.Path, env.Options)...)
+		}
+	}
+	paths = append(paths, env.secretPaths...)
+
+	return paths
+}
+
+// sortedNames returns sorted names of the variables. Only names are
+// logged because values may contain secrets.
+func sortedNames(variables map[string]string) []string {
+	names := make([]string, 0, len(variables))
+	for name := range variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// resolveSecretFiles sets variables from *_FILE variables which point to
+// readable files. Only variables from configs and preset ones are resolved,
+// inherited ones like LOG_FILE or HISTFILE are left intact. Returns the
+// list of resolved paths.
+func resolveSecretFiles(variables map[string]string, origins map[string]string, dropFileVariables bool) (paths []string) {
+	names := make([]string, 0)
+	for name, value := range variables {
+		if value == unsetValue || origins[name] == OriginInherited {
+			continue
+		}
+		if strings.HasSuffix(name, opts.SecretFileSuffix) && len(name) > len(opts.SecretFileSuffix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	resolvedPaths := make(map[string]bool, len(names))
+	for _, name := range names {
+		path := variables[name]
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"name":  name,
+				"path":  path,
+				"error": err,
+			}).Warn("Cannot read secret file, skip.")
+			continue
+		}
+
+		secretName := strings.TrimSuffix(name, opts.SecretFileSuffix)
+		if _, ok := variables[secretName]; ok {
+			log.WithFields(log.Fields{
+				"name": secretName,
+				"path": path,
+			}).Warn("Variable is overridden by the content of secret file.")
+		}
+
+		variables[secretName] = strings.TrimRight(string(content), "\r\n")
+		origins[secretName] = OriginSecretFile + opts.ConfigSourceSeparator + path
+		if dropFileVariables {
+			delete(variables, name)
+			delete(origins, name)
+		}
+		resolvedPaths[path] = true
+	}
+
+	for path := range resolvedPaths {
+		paths = append(paths, path)
 	}
+	sort.Strings(paths)
 
 	return
 }
 
+// Variables returns a copy of current environment snapshot.
+func (env *Environment) Variables() map[string]string {
+	env.lock.RLock()
+	defer env.lock.RUnlock()
+
+	variables := make(map[string]string, len(env.snapshot))
+	for name, value := range env.snapshot {
+		variables[name] = value
+	}
+
+	return variables
+}
+
+// Origins returns the origins of variables of current environment
+// snapshot: config sources they are taken from, OriginInherited,
+// OriginPredefined, OriginSecretFile or OriginSchemaDefault.
+func (env *Environment) Origins() map[string]string {
+	env.lock.RLock()
+	defer env.lock.RUnlock()
+
+	origins := make(map[string]string, len(env.origins))
+	for name, origin := range env.origins {
+		origins[name] = origin
+	}
+
+	return origins
+}
+
+// Environ returns current environment snapshot as a sorted list of
+// NAME=value strings (like exec.Cmd.Env expects).
+func (env *Environment) Environ() []string {
+	variables := env.Variables()
+
+	environ := make([]string, 0, len(variables))
+	for name, value := range variables {
+		environ = append(environ, name+"="+value)
+	}
+	sort.Strings(environ)
+
+	return environ
+}
+
 // NewEnvironment returns new Environment struct pointer and error if update
 // failed.
 func NewEnvironment(options *opts.Options) (env *Environment, err error) {
 	env = &Environment{
-		Options:         options,
-		parser:          getParser(options.ConfigFormat),
-		previousUpdates: make(map[string]string),
+		Options:   options,
+		inherited: environToMap(os.Environ()),
+		lock:      new(sync.RWMutex),
+		snapshot:  make(map[string]string),
+		origins:   make(map[string]string),
 	}
 	err = env.Update()
 
 	return
 }
+
+// environToMap converts the list of NAME=value strings (like os.Environ
+// returns) into map.
+func environToMap(environ []string) map[string]string {
+	converted := make(map[string]string, len(environ))
+
+	for _, item := range environ {
+		split := strings.SplitN(item, "=", 2)
+		if len(split) == 2 {
+			converted[split[0]] = split[1]
+		}
+	}
+
+	return converted
+}
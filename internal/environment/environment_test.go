@@ -3,6 +3,7 @@ package environment
 import (
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 
 	assert "github.com/stretchr/testify/assert"
@@ -25,12 +26,21 @@ func createTempJSON(data string) string {
 	return fd.Name()
 }
 
+func createJSONSources(paths ...string) []opts.ConfigSource {
+	sources := make([]opts.ConfigSource, 0, len(paths))
+	for _, path := range paths {
+		sources = append(sources, opts.ConfigSource{Format: opts.ConfigFormatJSON, Path: path})
+	}
+
+	return sources
+}
+
 func createOptions() *opts.Options {
 	options, _ := opts.NewOptions("term", // signal
 		[]string{}, // envs
 		0,          // gracefulTimeout
 		"json",     // configFormat
-		"",         // configPath
+		[]string{}, // configPaths
 		[]string{}, // pathsToTracks
 		"",         // lockFile
 		false,      // pty
@@ -65,7 +75,7 @@ func TestParseWithConfig(t *testing.T) {
 	configName := createTempJSON(envJSON)
 	defer os.Remove(configName)
 
-	env.Options.ConfigPath = configName
+	env.Options.ConfigSources = createJSONSources(configName)
 
 	values, err := env.Parse()
 
@@ -83,7 +93,7 @@ func TestParseWithIncorrectConfig(t *testing.T) {
 	configName := createTempJSON("")
 	defer os.Remove(configName)
 
-	env.Options.ConfigPath = configName
+	env.Options.ConfigSources = createJSONSources(configName)
 
 	_, err := env.Parse()
 
@@ -94,7 +104,10 @@ func TestUpdateWithConfigFormatNone(t *testing.T) {
 	options := createOptions()
 	env, _ := NewEnvironment(options)
 
-	env.Options.ConfigFormat = opts.ConfigFormatNone
+	configName := createTempJSON("")
+	defer os.Remove(configName)
+
+	env.Options.ConfigSources = []opts.ConfigSource{{Format: opts.ConfigFormatNone, Path: configName}}
 
 	err := env.Update()
 
@@ -108,7 +121,7 @@ func TestUpdateWithIncorrectConfig(t *testing.T) {
 	configName := createTempJSON("")
 	defer os.Remove(configName)
 
-	env.Options.ConfigPath = configName
+	env.Options.ConfigSources = createJSONSources(configName)
 
 	err := env.Update()
 
@@ -122,14 +135,14 @@ func TestUpdateWithProperConfig(t *testing.T) {
 	configName := createTempJSON(envJSON)
 	defer os.Remove(configName)
 
-	env.Options.ConfigPath = configName
+	env.Options.ConfigSources = createJSONSources(configName)
 
 	err := env.Update()
 
 	assert.Nil(t, err)
-	assert.Equal(t, os.Getenv("hello"), "world")
-	assert.Equal(t, os.Getenv("int_key"), "1")
-	assert.Equal(t, os.Getenv("float_key"), "1.1")
+	assert.Equal(t, env.Variables()["hello"], "world")
+	assert.Equal(t, env.Variables()["int_key"], "1")
+	assert.Equal(t, env.Variables()["float_key"], "1.1")
 }
 
 func TestUpdateWithChangedConfig(t *testing.T) {
@@ -139,7 +152,7 @@ func TestUpdateWithChangedConfig(t *testing.T) {
 	configName := createTempJSON(envJSON)
 	defer os.Remove(configName)
 
-	env.Options.ConfigPath = configName
+	env.Options.ConfigSources = createJSONSources(configName)
 
 	env.Update()
 
@@ -149,9 +162,9 @@ func TestUpdateWithChangedConfig(t *testing.T) {
 	err := env.Update()
 
 	assert.Nil(t, err)
-	assert.Equal(t, os.Getenv("hello"), "bye")
-	assert.Equal(t, os.Getenv("int_key"), "")
-	assert.Equal(t, os.Getenv("float_key"), "")
+	assert.Equal(t, env.Variables()["hello"], "bye")
+	assert.Equal(t, env.Variables()["int_key"], "")
+	assert.Equal(t, env.Variables()["float_key"], "")
 }
 
 func TestUpdateWithPredefinedValues(t *testing.T) {
@@ -161,21 +174,411 @@ func TestUpdateWithPredefinedValues(t *testing.T) {
 	configName := createTempJSON(envJSON)
 	defer os.Remove(configName)
 
-	env.Options.ConfigPath = configName
+	env.Options.ConfigSources = createJSONSources(configName)
 	env.Options.Envs = map[string]string{
 		"int_key": "2",
 	}
 
 	env.Update()
-	assert.Equal(t, os.Getenv("hello"), "world")
-	assert.Equal(t, os.Getenv("int_key"), "2")
-	assert.Equal(t, os.Getenv("float_key"), "1.1")
+	assert.Equal(t, env.Variables()["hello"], "world")
+	assert.Equal(t, env.Variables()["int_key"], "2")
+	assert.Equal(t, env.Variables()["float_key"], "1.1")
 
 	const changedJSON = "{\"hello\": \"bye\"}"
 	ioutil.WriteFile(configName, []byte(changedJSON), os.FileMode(0666))
 
 	env.Update()
-	assert.Equal(t, os.Getenv("hello"), "bye")
-	assert.Equal(t, os.Getenv("int_key"), "2")
-	assert.Equal(t, os.Getenv("float_key"), "")
+	assert.Equal(t, env.Variables()["hello"], "bye")
+	assert.Equal(t, env.Variables()["int_key"], "2")
+	assert.Equal(t, env.Variables()["float_key"], "")
+}
+
+func TestParseLayeredConfigs(t *testing.T) {
+	options := createOptions()
+	env, _ := NewEnvironment(options)
+
+	baseName := createTempJSON(envJSON)
+	defer os.Remove(baseName)
+	overrideName := createTempJSON("hello: override\nnew_key: value\n")
+	defer os.Remove(overrideName)
+
+	env.Options.ConfigSources = []opts.ConfigSource{
+		{Format: opts.ConfigFormatJSON, Path: baseName},
+		{Format: opts.ConfigFormatYAML, Path: overrideName},
+	}
+
+	values, err := env.Parse()
+
+	assert.Nil(t, err)
+	assert.Equal(t, len(values), 4)
+	assert.Equal(t, values["hello"], "override")
+	assert.Equal(t, values["int_key"], "1")
+	assert.Equal(t, values["float_key"], "1.1")
+	assert.Equal(t, values["new_key"], "value")
+}
+
+func TestParseLayeredConfigsWithIncorrectOne(t *testing.T) {
+	options := createOptions()
+	env, _ := NewEnvironment(options)
+
+	baseName := createTempJSON(envJSON)
+	defer os.Remove(baseName)
+	brokenName := createTempJSON("")
+	defer os.Remove(brokenName)
+
+	env.Options.ConfigSources = createJSONSources(baseName, brokenName)
+
+	_, err := env.Parse()
+
+	assert.NotNil(t, err)
+}
+
+func TestUpdateWithChangedLayeredConfigs(t *testing.T) {
+	options := createOptions()
+	env, _ := NewEnvironment(options)
+
+	baseName := createTempJSON(envJSON)
+	defer os.Remove(baseName)
+	overrideName := createTempJSON("{\"hello\": \"override\", \"layered_key\": \"1\"}")
+	defer os.Remove(overrideName)
+
+	env.Options.ConfigSources = createJSONSources(baseName, overrideName)
+
+	err := env.Update()
+	assert.Nil(t, err)
+	assert.Equal(t, env.Variables()["hello"], "override")
+	assert.Equal(t, env.Variables()["layered_key"], "1")
+
+	ioutil.WriteFile(overrideName, []byte("{}"), os.FileMode(0666))
+
+	err = env.Update()
+	assert.Nil(t, err)
+	assert.Equal(t, env.Variables()["hello"], "world")
+	assert.Equal(t, env.Variables()["int_key"], "1")
+	assert.Equal(t, env.Variables()["layered_key"], "")
+}
+
+func TestParseWithInterpolation(t *testing.T) {
+	options := createOptions()
+	env, _ := NewEnvironment(options)
+
+	configName := createTempJSON(`{"user": "${predefined}", "url": "db://${user}@${host:-localhost}"}`)
+	defer os.Remove(configName)
+
+	env.Options.ConfigSources = createJSONSources(configName)
+	env.Options.Envs = map[string]string{"predefined": "admin"}
+
+	values, err := env.Parse()
+	assert.Nil(t, err)
+	assert.Equal(t, values["url"], "db://${user}@${host:-localhost}")
+
+	env.Options.Interpolate = true
+
+	values, err = env.Parse()
+	assert.Nil(t, err)
+	assert.Equal(t, values["user"], "admin")
+	assert.Equal(t, values["url"], "db://admin@localhost")
+}
+
+func TestParseWithInterpolationInheritance(t *testing.T) {
+	options := createOptions()
+	env, _ := NewEnvironment(options)
+
+	configName := createTempJSON(`{"secret": "${AWS_SECRET_ACCESS_KEY}", "path": "${PATH}"}`)
+	defer os.Remove(configName)
+
+	env.inherited = map[string]string{"AWS_SECRET_ACCESS_KEY": "key", "PATH": "/bin"}
+	env.Options.ConfigSources = createJSONSources(configName)
+	env.Options.Interpolate = true
+
+	values, err := env.Parse()
+	assert.Nil(t, err)
+	assert.Equal(t, values["secret"], "key")
+	assert.Equal(t, values["path"], "/bin")
+
+	env.Options.Inheritance = opts.Inheritance{Deny: []string{"AWS_*"}}
+
+	values, err = env.Parse()
+	assert.Nil(t, err)
+	assert.Equal(t, values["secret"], "")
+	assert.Equal(t, values["path"], "/bin")
+
+	env.Options.Inheritance = opts.Inheritance{Clean: true}
+
+	values, err = env.Parse()
+	assert.Nil(t, err)
+	assert.Equal(t, values["secret"], "")
+	assert.Equal(t, values["path"], "")
+}
+
+func TestParseWithInterpolationCycle(t *testing.T) {
+	options := createOptions()
+	env, _ := NewEnvironment(options)
+
+	configName := createTempJSON(`{"a": "${b}", "b": "${a}"}`)
+	defer os.Remove(configName)
+
+	env.Options.ConfigSources = createJSONSources(configName)
+	env.Options.Interpolate = true
+
+	_, err := env.Parse()
+	assert.NotNil(t, err)
+}
+
+func TestEnviron(t *testing.T) {
+	options := createOptions()
+	env, _ := NewEnvironment(options)
+
+	configName := createTempJSON(envJSON)
+	defer os.Remove(configName)
+
+	env.inherited = map[string]string{
+		"PATH":           "/bin",
+		"AWS_SECRET_KEY": "secret",
+		"hello":          "inherited",
+	}
+	env.Options.ConfigSources = createJSONSources(configName)
+	env.Options.Envs = map[string]string{"int_key": "2"}
+	env.Update()
+
+	environ := env.Environ()
+	assert.Equal(t, environ, []string{
+		"AWS_SECRET_KEY=secret",
+		"PATH=/bin",
+		"float_key=1.1",
+		"hello=world",
+		"int_key=2",
+	})
+
+	env.Options.Inheritance = opts.Inheritance{Deny: []string{"AWS_*"}}
+	env.Update()
+	environ = env.Environ()
+	assert.Equal(t, environ, []string{
+		"PATH=/bin",
+		"float_key=1.1",
+		"hello=world",
+		"int_key=2",
+	})
+
+	env.Options.Inheritance = opts.Inheritance{Clean: true}
+	env.Update()
+	environ = env.Environ()
+	assert.Equal(t, environ, []string{
+		"float_key=1.1",
+		"hello=world",
+		"int_key=2",
+	})
+}
+
+func TestUpdateDoesNotChangeProcessEnvironment(t *testing.T) {
+	options := createOptions()
+	env, _ := NewEnvironment(options)
+
+	configName := createTempJSON(`{"GUIDEDOG_TEST_VARIABLE": "1"}`)
+	defer os.Remove(configName)
+
+	env.Options.ConfigSources = createJSONSources(configName)
+	env.Options.Envs = map[string]string{"GUIDEDOG_TEST_PREDEFINED": "2"}
+
+	err := env.Update()
+
+	assert.Nil(t, err)
+	assert.Equal(t, env.Variables()["GUIDEDOG_TEST_VARIABLE"], "1")
+	assert.Equal(t, env.Variables()["GUIDEDOG_TEST_PREDEFINED"], "2")
+	assert.Equal(t, os.Getenv("GUIDEDOG_TEST_VARIABLE"), "")
+	assert.Equal(t, os.Getenv("GUIDEDOG_TEST_PREDEFINED"), "")
+}
+
+func TestUpdateKeepsSnapshotOnError(t *testing.T) {
+	options := createOptions()
+	env, _ := NewEnvironment(options)
+
+	configName := createTempJSON(envJSON)
+	defer os.Remove(configName)
+
+	env.Options.ConfigSources = createJSONSources(configName)
+	env.Update()
+
+	environ := env.Environ()
+	ioutil.WriteFile(configName, []byte("{"), os.FileMode(0666))
+
+	err := env.Update()
+
+	assert.NotNil(t, err)
+	assert.Equal(t, env.Environ(), environ)
+	assert.Equal(t, env.Variables()["hello"], "world")
+}
+
+func TestVariablesReturnsCopy(t *testing.T) {
+	options := createOptions()
+	env, _ := NewEnvironment(options)
+
+	env.Options.Envs = map[string]string{"key": "value"}
+	env.Update()
+
+	variables := env.Variables()
+	variables["key"] = "changed"
+
+	assert.Equal(t, env.Variables()["key"], "value")
+}
+
+func TestUpdateWithSecretFiles(t *testing.T) {
+	options := createOptions()
+	env, _ := NewEnvironment(options)
+
+	secretName := createTempJSON("secret\n")
+	defer os.Remove(secretName)
+
+	env.inherited = map[string]string{
+		"INHERITED_FILE": secretName,
+		"LOG_FILE":       secretName,
+	}
+	env.Options.Envs = map[string]string{
+		"PASSWORD_FILE": secretName,
+		"ABSENT_FILE":   "/WTF",
+		"_FILE":         secretName,
+	}
+
+	env.Update()
+	assert.Equal(t, env.Variables()["PASSWORD_FILE"], secretName)
+	_, ok := env.Variables()["PASSWORD"]
+	assert.False(t, ok)
+
+	env.Options.SecretFiles = opts.SecretFiles{Enabled: true}
+	env.Update()
+
+	variables := env.Variables()
+	assert.Equal(t, variables["PASSWORD"], "secret")
+	assert.Equal(t, variables["PASSWORD_FILE"], secretName)
+	assert.Equal(t, variables["INHERITED_FILE"], secretName)
+	_, ok = variables["INHERITED"]
+	assert.False(t, ok)
+	assert.Equal(t, variables["LOG_FILE"], secretName)
+	_, ok = variables["LOG"]
+	assert.False(t, ok)
+	assert.Equal(t, variables["ABSENT_FILE"], "/WTF")
+	_, ok = variables["ABSENT"]
+	assert.False(t, ok)
+	_, ok = variables[""]
+	assert.False(t, ok)
+	assert.Equal(t, env.TrackedPaths(), []string{secretName})
+
+	env.Options.SecretFiles = opts.SecretFiles{Enabled: true, DropFileVariables: true}
+	env.Update()
+
+	variables = env.Variables()
+	assert.Equal(t, variables["PASSWORD"], "secret")
+	_, ok = variables["PASSWORD_FILE"]
+	assert.False(t, ok)
+	assert.Equal(t, variables["ABSENT_FILE"], "/WTF")
+	assert.Equal(t, variables["LOG_FILE"], secretName)
+}
+
+func TestTrackedPaths(t *testing.T) {
+	options := createOptions()
+	env, _ := NewEnvironment(options)
+
+	configName := createTempJSON(envJSON)
+	defer os.Remove(configName)
+	secretName := createTempJSON("secret")
+	defer os.Remove(secretName)
+	logName := createTempJSON("log")
+	defer os.Remove(logName)
+
+	env.inherited = map[string]string{"LOG_FILE": logName}
+	env.Options.ConfigSources = createJSONSources(configName)
+	env.Options.Envs = map[string]string{"PASSWORD_FILE": secretName}
+	env.Options.SecretFiles = opts.SecretFiles{Enabled: true}
+	env.Update()
+
+	assert.Equal(t, env.TrackedPaths(), []string{configName, secretName})
+}
+
+func TestUpdateWithSchema(t *testing.T) {
+	filename := createTempJSON(`{"PORT": "80", "DATABASE_URL": "postgres://localhost/db"}`)
+	defer os.Remove(filename)
+
+	options := createOptions()
+	options.ConfigSources = createJSONSources(filename)
+	options.Schema = opts.Schema{Variables: []opts.SchemaVariable{
+		{Name: "DATABASE_URL", Required: true, Type: opts.VariableTypeURL},
+		{Name: "LOG_LEVEL", Default: "info", HasDefault: true},
+		{Name: "PORT", Type: opts.VariableTypeInt},
+	}}
+
+	env, err := NewEnvironment(options)
+	assert.Nil(t, err)
+	assert.Equal(t, env.Variables()["PORT"], "80")
+	assert.Equal(t, env.Variables()["LOG_LEVEL"], "info")
+
+	ioutil.WriteFile(filename, []byte(`{"PORT": "http"}`), os.FileMode(0666))
+	err = env.Update()
+	assert.IsType(t, err, &ValidationError{})
+	assert.Len(t, err.(*ValidationError).Violations, 2)
+	assert.Contains(t, err.Error(), "DATABASE_URL: is required")
+	assert.Contains(t, err.Error(), "PORT: \"http\" is not a valid int")
+	assert.Equal(t, env.Variables()["PORT"], "80")
+}
+
+func TestUpdateEnvDirRemovesVariables(t *testing.T) {
+	tempDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(tempDir)
+
+	filename := createTempJSON(`{"FOO": "bar", "REF": "${FOO:-default}"}`)
+	defer os.Remove(filename)
+	ioutil.WriteFile(filepath.Join(tempDir, "FOO"), []byte{}, os.FileMode(0666))
+	ioutil.WriteFile(filepath.Join(tempDir, "HOME"), []byte{}, os.FileMode(0666))
+
+	options := createOptions()
+	options.Interpolate = true
+	options.ConfigSources = append(createJSONSources(filename),
+		opts.ConfigSource{Format: opts.ConfigFormatEnvDir, Path: tempDir})
+
+	env, err := NewEnvironment(options)
+	assert.Nil(t, err)
+
+	_, ok := env.Variables()["FOO"]
+	assert.False(t, ok)
+	_, ok = env.Variables()["HOME"]
+	assert.False(t, ok)
+	assert.Equal(t, env.Variables()["REF"], "default")
+}
+
+func TestTrackedPathsRecursiveEnvDir(t *testing.T) {
+	tempDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(tempDir)
+
+	os.MkdirAll(filepath.Join(tempDir, "db", "replica"), os.FileMode(0777))
+
+	options := createOptions()
+	options.ConfigSources = []opts.ConfigSource{{Format: opts.ConfigFormatEnvDir, Path: tempDir}}
+
+	env, _ := NewEnvironment(options)
+	assert.Equal(t, env.TrackedPaths(), []string{tempDir})
+
+	options.EnvDir.Recursive = true
+	assert.Equal(t, env.TrackedPaths(), []string{
+		tempDir,
+		filepath.Join(tempDir, "db"),
+		filepath.Join(tempDir, "db", "replica"),
+	})
+}
+
+func TestUpdateNullRemovesVariables(t *testing.T) {
+	baseName := createTempJSON(`{"FOO": "bar", "KEEP": "1"}`)
+	defer os.Remove(baseName)
+	overrideName := createTempJSON(`{"FOO": null, "HOME": null}`)
+	defer os.Remove(overrideName)
+
+	options := createOptions()
+	options.ConfigSources = createJSONSources(baseName, overrideName)
+
+	env, err := NewEnvironment(options)
+	assert.Nil(t, err)
+
+	_, ok := env.Variables()["FOO"]
+	assert.False(t, ok)
+	_, ok = env.Variables()["HOME"]
+	assert.False(t, ok)
+	assert.Equal(t, env.Variables()["KEEP"], "1")
 }
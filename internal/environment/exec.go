@@ -0,0 +1,65 @@
+// Package environment has a definition of Environment struct with parser.
+// This file contains parsing of configs which are printed by commands.
+// Output of commands is kept in memory only.
+package environment
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+
+	log "github.com/Sirupsen/logrus"
+
+	opts "github.com/9seconds/guidedog/internal/options"
+)
+
+// execShell is a shell which runs commands of config sources.
+var execShell = []string{"/bin/sh", "-c"}
+
+// getExecParser returns a parser for configs of the given format which
+// are printed by commands. Command is run in shell, its stdout is parsed
+// by the content parser of the format.
+func getExecParser(configFormat opts.ConfigFormat) environmentParser {
+	parser := getContentParser(configFormat)
+
+	return func(command string, options *opts.Options) (map[string]string, error) {
+		if parser == nil {
+			return nil, fmt.Errorf("Config format %s cannot be read from command output", configFormat)
+		}
+
+		output, err := runConfigCommand(command)
+		if err != nil {
+			return nil, err
+		}
+
+		envs, err := parser(output, options)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"command": command,
+				"error":   err,
+			}).Error("Cannot parse command output.")
+		}
+
+		return envs, err
+	}
+}
+
+// runConfigCommand runs the command in shell and returns its stdout.
+func runConfigCommand(command string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.Command(execShell[0], append(execShell[1:], command)...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		log.WithFields(log.Fields{
+			"command": command,
+			"stderr":  stderr.String(),
+			"error":   err,
+		}).Error("Cannot run config command.")
+		return nil, fmt.Errorf("Command %s failed: %v", command, err)
+	}
+
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,49 @@
+package environment
+
+import (
+	"os"
+	"testing"
+
+	assert "github.com/stretchr/testify/assert"
+
+	opts "github.com/9seconds/guidedog/internal/options"
+)
+
+func TestExecParserDotEnv(t *testing.T) {
+	result, err := getExecParser(opts.ConfigFormatDotEnv)("echo HELLO=world; echo 'KEY=\"a b\"'", createOptions())
+
+	assert.Nil(t, err)
+	assert.Equal(t, result, map[string]string{"HELLO": "world", "KEY": "a b"})
+}
+
+func TestExecParserJSON(t *testing.T) {
+	result, err := getExecParser(opts.ConfigFormatJSON)(`echo '{"db": {"host": "localhost"}}'`, createOptions())
+
+	assert.Nil(t, err)
+	assert.Equal(t, result, map[string]string{"DB_HOST": "localhost"})
+}
+
+func TestExecParserFail(t *testing.T) {
+	_, err := getExecParser(opts.ConfigFormatDotEnv)("echo HELLO=world; exit 1", createOptions())
+	assert.NotNil(t, err)
+
+	_, err = getExecParser(opts.ConfigFormatJSON)("echo HELLO=world", createOptions())
+	assert.NotNil(t, err)
+
+	_, err = getExecParser(opts.ConfigFormatEnvDir)("echo HELLO=world", createOptions())
+	assert.NotNil(t, err)
+}
+
+func TestUpdateWithExecSource(t *testing.T) {
+	filename := createTempJSON(`{"FOO": "bar"}`)
+	defer os.Remove(filename)
+
+	options := createOptions()
+	options.ConfigSources = append(createJSONSources(filename),
+		opts.ConfigSource{Exec: true, Format: opts.ConfigFormatDotEnv, Path: "echo FOO=baz"})
+
+	env, err := NewEnvironment(options)
+	assert.Nil(t, err)
+	assert.Equal(t, env.Variables()["FOO"], "baz")
+	assert.Equal(t, env.TrackedPaths(), []string{filename})
+}
@@ -0,0 +1,115 @@
+// Package environment has a definition of Environment struct with parser.
+// This file contains interpolation of ${VAR} references in config values.
+package environment
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// interpolationPattern matches $$ (escaped dollar sign), ${VAR} and
+// ${VAR:-default} references.
+var interpolationPattern = regexp.MustCompile(`\$\$|\$\{([^}:]+)(:-([^}]*))?\}`)
+
+// interpolator resolves references in config values against other config
+// values, predefined values (set explicitly by user) and inherited
+// environment filtered according to the inheritance rules.
+type interpolator struct {
+	variables  map[string]string
+	predefined map[string]string
+	inherited  map[string]string
+	resolved   map[string]string
+	chain      []string
+}
+
+// interpolate expands ${VAR} and ${VAR:-default} references in values of
+// variables. References are resolved against predefined values first,
+// then against other variables and then against inherited environment. If
+// variable references itself, reference is resolved against predefined
+// values and inherited environment only (so PATH=${PATH}:/opt/bin works).
+// Cycles are reported as errors.
+func interpolate(variables, predefined, inherited map[string]string) (map[string]string, error) {
+	interp := &interpolator{
+		variables:  variables,
+		predefined: predefined,
+		inherited:  inherited,
+		resolved:   make(map[string]string, len(variables)),
+		chain:      make([]string, 0, len(variables)),
+	}
+
+	for name := range variables {
+		if _, err := interp.resolve(name); err != nil {
+			log.WithField("error", err).Error("Cannot interpolate variables.")
+			return nil, err
+		}
+	}
+
+	return interp.resolved, nil
+}
+
+// resolve returns the value of the variable with all references expanded.
+func (interp *interpolator) resolve(name string) (string, error) {
+	if value, ok := interp.resolved[name]; ok {
+		return value, nil
+	}
+
+	for idx, chainName := range interp.chain {
+		if chainName == name {
+			cycle := append(interp.chain[idx:len(interp.chain):len(interp.chain)], name)
+			return "", fmt.Errorf("Cycle in variable interpolation: %s", strings.Join(cycle, " -> "))
+		}
+	}
+
+	interp.chain = append(interp.chain, name)
+	defer func() {
+		interp.chain = interp.chain[:len(interp.chain)-1]
+	}()
+
+	var err error
+	value := interpolationPattern.ReplaceAllStringFunc(interp.variables[name], func(match string) string {
+		if err != nil {
+			return match
+		}
+		if match == "$$" {
+			return "$"
+		}
+
+		groups := interpolationPattern.FindStringSubmatch(match)
+		reference, hasDefault, defaultValue := groups[1], groups[2] != "", groups[3]
+
+		var referenceValue string
+		referenceValue, err = interp.lookup(name, reference)
+		if referenceValue == "" && hasDefault {
+			return defaultValue
+		}
+
+		return referenceValue
+	})
+	if err != nil {
+		return "", err
+	}
+
+	interp.resolved[name] = value
+
+	return value, nil
+}
+
+// lookup returns the value of the reference from the variable with the
+// given name.
+func (interp *interpolator) lookup(name, reference string) (string, error) {
+	if value, ok := interp.predefined[reference]; ok {
+		return value, nil
+	}
+
+	if value, ok := interp.variables[reference]; ok && reference != name {
+		if value == unsetValue {
+			return "", nil
+		}
+		return interp.resolve(reference)
+	}
+
+	return interp.inherited[reference], nil
+}
@@ -0,0 +1,57 @@
+package environment
+
+import (
+	"testing"
+
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestInterpolateReferences(t *testing.T) {
+	variables := map[string]string{
+		"DB_USER": "user",
+		"DB_HOST": "${HOST}",
+		"HOST":    "localhost",
+		"DB_URL":  "postgres://${DB_USER}@${DB_HOST}/${DB_NAME:-main}",
+		"PLAIN":   "$HOME and $$ and ${}",
+	}
+
+	result, err := interpolate(variables, map[string]string{}, map[string]string{})
+
+	assert.Nil(t, err)
+	assert.Equal(t, len(result), 5)
+	assert.Equal(t, result["DB_HOST"], "localhost")
+	assert.Equal(t, result["DB_URL"], "postgres://user@localhost/main")
+	assert.Equal(t, result["PLAIN"], "$HOME and $ and ${}")
+}
+
+func TestInterpolatePrecedence(t *testing.T) {
+	variables := map[string]string{
+		"NAME":    "config",
+		"FROM":    "${NAME}",
+		"HOME":    "${HOME}/app",
+		"DEFAULT": "${EMPTY:-default}",
+		"ABSENT":  "${ABSENT_VAR}",
+	}
+	predefined := map[string]string{"NAME": "predefined"}
+	inherited := map[string]string{"HOME": "/home/user", "NAME": "inherited", "EMPTY": ""}
+
+	result, err := interpolate(variables, predefined, inherited)
+
+	assert.Nil(t, err)
+	assert.Equal(t, result["FROM"], "predefined")
+	assert.Equal(t, result["HOME"], "/home/user/app")
+	assert.Equal(t, result["DEFAULT"], "default")
+	assert.Equal(t, result["ABSENT"], "")
+}
+
+func TestInterpolateCycle(t *testing.T) {
+	variables := map[string]string{
+		"A": "${B}",
+		"B": "${C}",
+		"C": "${A}",
+	}
+
+	_, err := interpolate(variables, map[string]string{}, map[string]string{})
+
+	assert.NotNil(t, err)
+}
@@ -4,13 +4,17 @@
 package environment
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
+	toml "github.com/BurntSushi/toml"
 	log "github.com/Sirupsen/logrus"
 	ini "github.com/vaughan0/go-ini"
 	yaml "gopkg.in/yaml.v2"
@@ -24,7 +28,11 @@ type (
 
 	// environmentParser is just a signature of the function which parsers
 	// config for environment variables.
-	environmentParser func(string) (map[string]string, error)
+	environmentParser func(string, *opts.Options) (map[string]string, error)
+
+	// contentParser is a signature of the function which parses the content
+	// of config stored in a single file.
+	contentParser func([]byte, *opts.Options) (map[string]string, error)
 )
 
 func getParser(configFormat opts.ConfigFormat) environmentParser {
@@ -39,21 +47,43 @@ func getParser(configFormat opts.ConfigFormat) environmentParser {
 		return configFormatINIParser
 	case opts.ConfigFormatEnvDir:
 		return configFormatEnvDirParser
+	case opts.ConfigFormatDotEnv:
+		return configFormatDotEnvParser
+	case opts.ConfigFormatTOML:
+		return configFormatTOMLParser
+	case opts.ConfigFormatProperties:
+		return configFormatPropertiesParser
 	default:
 		return configFormatNoneParser
 	}
 }
 
-// configFormatNoneParsers basically does nothing, just returns an empty list.
-// the good thing, it never returns error.
-func configFormatNoneParser(path string) (envs map[string]string, err error) {
-	return make(map[string]string), nil
+// getContentParser returns parser of the content for the formats which are
+// stored in a single file. Returns nil for other formats.
+func getContentParser(configFormat opts.ConfigFormat) contentParser {
+	switch configFormat {
+	case opts.ConfigFormatJSON:
+		return parseJSONContent
+	case opts.ConfigFormatYAML:
+		return parseYAMLContent
+	case opts.ConfigFormatINI:
+		return parseINIContent
+	case opts.ConfigFormatDotEnv:
+		return parseDotEnvContent
+	case opts.ConfigFormatTOML:
+		return parseTOMLContent
+	case opts.ConfigFormatProperties:
+		return parsePropertiesContent
+	default:
+		return nil
+	}
 }
 
-// configUnmarshall does a basic logic for managing JSON and YAML configs.
-func configUnmarshall(convertFromFloat bool,
-	unpack unmarshal,
-	filename string) (envs map[string]string, err error) {
+// parseConfigFile reads the config file and parses its content with the
+// given parser.
+func parseConfigFile(filename string,
+	options *opts.Options,
+	parser contentParser) (envs map[string]string, err error) {
 	content, err := ioutil.ReadFile(filename)
 	if err != nil {
 		log.WithFields(log.Fields{
@@ -63,67 +93,292 @@ func configUnmarshall(convertFromFloat bool,
 		return
 	}
 
-	var unmarshalled map[string]interface{}
-	err = unpack(content, &unmarshalled)
+	envs, err = parser(content, options)
 	if err != nil {
 		log.WithFields(log.Fields{
 			"filename": filename,
 			"error":    err,
 		}).Error("Cannot parse config file")
+	}
+
+	return
+}
+
+// configFormatNoneParsers basically does nothing, just returns an empty list.
+// the good thing, it never returns error.
+func configFormatNoneParser(path string, options *opts.Options) (envs map[string]string, err error) {
+	return make(map[string]string), nil
+}
+
+// configUnmarshall does a basic logic for managing JSON, YAML and TOML
+// configs.
+func configUnmarshall(convertFromFloat bool,
+	unpack unmarshal,
+	content []byte,
+	options *opts.Options) (envs map[string]string, err error) {
+	var unmarshalled map[string]interface{}
+	err = unpack(content, &unmarshalled)
+	if err != nil {
 		return
 	}
-	log.WithField("structure", unmarshalled).Debug("Unmarshalled structure.")
+	log.Debug("Unmarshalled structure.")
 
 	envs = make(map[string]string)
-	for key, value := range unmarshalled {
-		if strValue, ok := value.(string); ok {
-			envs[key] = strValue
+	err = flattenStructure(envs, options, nil, unmarshalled)
+	if err != nil {
+		return nil, err
+	}
+
+	return
+}
+
+// flattenStructure walks through the nested structure and fills envs with
+// its values. Names of nested values are composed according to naming.
+func flattenStructure(envs map[string]string,
+	options *opts.Options,
+	path []string,
+	structure map[string]interface{}) error {
+	for key, value := range structure {
+		currentPath := append(path[:len(path):len(path)], key)
+
+		if nested, ok := toStringMap(value); ok {
+			if err := flattenStructure(envs, options, currentPath, nested); err != nil {
+				return err
+			}
 			continue
 		}
 
-		// JSON returns float64 for numbers. The same YAML does for floats.
-		if floatValue, ok := value.(float64); ok {
-			envs[key] = strconv.FormatFloat(floatValue, 'f', -1, 64)
-			continue
+		name := options.Naming.Compose(currentPath)
+		strValue := unsetValue
+		if value == nil && options.Values.Null == opts.NullModeUnset {
+			log.WithField("name", name).Debug("Null value unsets variable.")
+		} else {
+			var err error
+			if strValue, err = convertValue(value, options.Values); err != nil {
+				return err
+			}
 		}
 
-		// YAML returns ints like that.
-		if intValue, ok := value.(int); ok {
-			envs[key] = strconv.Itoa(intValue)
-			continue
+		if _, ok := envs[name]; ok {
+			log.WithField("name", name).Error("Name collision.")
+			return fmt.Errorf("Several values are mapped to %s", name)
 		}
+		envs[name] = strValue
+	}
+
+	return nil
+}
 
-		log.WithField("value", value).Error("Cannot convert to string.")
-		return nil, fmt.Errorf("Cannot convert %v to string", value)
+// toStringMap converts nested objects into maps with string keys. JSON
+// and TOML return map[string]interface{} but YAML returns
+// map[interface{}]interface{}.
+func toStringMap(value interface{}) (map[string]interface{}, bool) {
+	switch typedValue := value.(type) {
+	case map[string]interface{}:
+		return typedValue, true
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(typedValue))
+		for key, nestedValue := range typedValue {
+			converted[fmt.Sprint(key)] = nestedValue
+		}
+		return converted, true
 	}
 
-	return
+	return nil, false
+}
+
+// toSlice converts arrays into generic slices. TOML returns arrays of
+// tables as []map[string]interface{}.
+func toSlice(value interface{}) ([]interface{}, bool) {
+	switch typedValue := value.(type) {
+	case []interface{}:
+		return typedValue, true
+	case []map[string]interface{}:
+		converted := make([]interface{}, 0, len(typedValue))
+		for _, item := range typedValue {
+			converted = append(converted, item)
+		}
+		return converted, true
+	}
+
+	return nil, false
+}
+
+// normalizeStructure converts nested structure into something JSON encoder
+// understands (YAML maps have interface{} keys).
+func normalizeStructure(value interface{}) interface{} {
+	if nested, ok := toStringMap(value); ok {
+		normalized := make(map[string]interface{}, len(nested))
+		for key, nestedValue := range nested {
+			normalized[key] = normalizeStructure(nestedValue)
+		}
+		return normalized
+	}
+
+	if items, ok := toSlice(value); ok {
+		normalized := make([]interface{}, 0, len(items))
+		for _, item := range items {
+			normalized = append(normalized, normalizeStructure(item))
+		}
+		return normalized
+	}
+
+	return value
+}
+
+// convertValue converts scalars and arrays into string according to
+// given conversion rules.
+func convertValue(value interface{}, conversion opts.ValueConversion) (string, error) {
+	items, ok := toSlice(value)
+	if !ok {
+		return convertScalar(value)
+	}
+
+	if conversion.Array == opts.ArrayModeJSON {
+		encoded, err := json.Marshal(normalizeStructure(items))
+		if err != nil {
+			log.WithField("error", err).Error("Cannot encode array to JSON.")
+			return "", err
+		}
+		return string(encoded), nil
+	}
+
+	converted := make([]string, 0, len(items))
+	for _, item := range items {
+		if _, ok := toStringMap(item); ok {
+			return "", fmt.Errorf("Cannot join nested object %v", item)
+		}
+		if _, ok := toSlice(item); ok {
+			return "", fmt.Errorf("Cannot join nested array %v", item)
+		}
+
+		strItem, err := convertScalar(item)
+		if err != nil {
+			return "", err
+		}
+		converted = append(converted, strItem)
+	}
+
+	return strings.Join(converted, conversion.ArraySeparator), nil
+}
+
+// convertScalar converts scalar value into string.
+func convertScalar(value interface{}) (string, error) {
+	if value == nil {
+		return "", nil
+	}
+
+	if strValue, ok := value.(string); ok {
+		return strValue, nil
+	}
+
+	if boolValue, ok := value.(bool); ok {
+		return strconv.FormatBool(boolValue), nil
+	}
+
+	// JSON returns float64 for numbers. The same YAML does for floats.
+	if floatValue, ok := value.(float64); ok {
+		return strconv.FormatFloat(floatValue, 'f', -1, 64), nil
+	}
+
+	// YAML returns ints like that.
+	if intValue, ok := value.(int); ok {
+		return strconv.Itoa(intValue), nil
+	}
+
+	// TOML returns int64 for integers.
+	if intValue, ok := value.(int64); ok {
+		return strconv.FormatInt(intValue, 10), nil
+	}
+
+	// TOML has native datetimes.
+	if timeValue, ok := value.(time.Time); ok {
+		return timeValue.Format(time.RFC3339Nano), nil
+	}
+
+	log.WithField("type", fmt.Sprintf("%T", value)).Error("Cannot convert to string.")
+	return "", fmt.Errorf("Cannot convert %v to string", value)
 }
 
 // configFormatJSONParser parses JSON config.
-func configFormatJSONParser(filename string) (map[string]string, error) {
-	return configUnmarshall(true, json.Unmarshal, filename)
+func configFormatJSONParser(filename string, options *opts.Options) (map[string]string, error) {
+	return parseConfigFile(filename, options, parseJSONContent)
+}
+
+// parseJSONContent parses the content of JSON config.
+func parseJSONContent(content []byte, options *opts.Options) (map[string]string, error) {
+	return configUnmarshall(true, json.Unmarshal, content, options)
 }
 
 // configFormatYAMLParser parses YAML config.
-func configFormatYAMLParser(filename string) (map[string]string, error) {
-	return configUnmarshall(false, yaml.Unmarshal, filename)
+func configFormatYAMLParser(filename string, options *opts.Options) (map[string]string, error) {
+	return parseConfigFile(filename, options, parseYAMLContent)
+}
+
+// parseYAMLContent parses the content of YAML config.
+func parseYAMLContent(content []byte, options *opts.Options) (map[string]string, error) {
+	return configUnmarshall(false, yaml.Unmarshal, content, options)
+}
+
+// configFormatTOMLParser parses TOML config.
+func configFormatTOMLParser(filename string, options *opts.Options) (map[string]string, error) {
+	return parseConfigFile(filename, options, parseTOMLContent)
+}
+
+// parseTOMLContent parses the content of TOML config.
+func parseTOMLContent(content []byte, options *opts.Options) (map[string]string, error) {
+	return configUnmarshall(false, tomlUnmarshal, content, options)
+}
+
+// tomlUnmarshal adapts TOML decoder to unmarshal signature.
+func tomlUnmarshal(content []byte, value interface{}) error {
+	_, err := toml.Decode(string(content), value)
+	return err
 }
 
 // configFormatINIParser parses INI configs.
-func configFormatINIParser(filename string) (envs map[string]string, err error) {
-	file, err := ini.LoadFile(filename)
+func configFormatINIParser(filename string, options *opts.Options) (map[string]string, error) {
+	return parseConfigFile(filename, options, parseINIContent)
+}
+
+// parseINIContent parses the content of INI config. Sections are handled
+// according to INI mode: in merge mode all sections are merged on top of
+// the global one in alphabetical order, in prefix mode names are prefixed
+// with section names, in select mode only given sections are merged on top
+// of the global one in the given order.
+func parseINIContent(content []byte, options *opts.Options) (envs map[string]string, err error) {
+	file, err := ini.Load(bytes.NewReader(content))
 	if err != nil {
-		log.WithFields(log.Fields{
-			"filename": filename,
-			"error":    err,
-		}).Error("Cannot read from config file.")
 		return
 	}
 
 	envs = make(map[string]string)
-	for _, data := range file {
+	for key, value := range file[""] {
+		envs[key] = value
+	}
+
+	sections := options.INI.Sections
+	if options.INI.Mode != opts.INIModeSelect {
+		sections = make([]string, 0, len(file))
+		for name := range file {
+			if name != "" {
+				sections = append(sections, name)
+			}
+		}
+		sort.Strings(sections)
+	}
+
+	for _, name := range sections {
+		data, ok := file[name]
+		if !ok {
+			log.WithField("section", name).Error("Cannot find section.")
+			return nil, fmt.Errorf("Cannot find section %s", name)
+		}
+
 		for key, value := range data {
+			if options.INI.Mode == opts.INIModePrefix {
+				key = options.Naming.Compose([]string{name, key})
+			}
 			envs[key] = value
 		}
 	}
@@ -131,37 +386,83 @@ func configFormatINIParser(filename string) (envs map[string]string, err error)
 	return
 }
 
-// configFormatEnvDirParser parses directory in EnvDir way.
-func configFormatEnvDirParser(dirname string) (envs map[string]string, err error) {
+// configFormatEnvDirParser parses directory in EnvDir way. Please check
+// opts.EnvDirMode for the details how files are read. Nested directories
+// are read only if opts.EnvDir.Recursive is set.
+func configFormatEnvDirParser(dirname string, options *opts.Options) (envs map[string]string, err error) {
+	envs = make(map[string]string)
+	if err = readEnvDir(dirname, nil, options, envs); err != nil {
+		return nil, err
+	}
+
+	return
+}
+
+// readEnvDir reads variables from the directory into envs. Names of
+// variables are composed from the prefix (names of parent directories) and
+// the names of files.
+func readEnvDir(dirname string, prefix []string, options *opts.Options, envs map[string]string) error {
 	files, err := ioutil.ReadDir(dirname)
 	if err != nil {
 		log.WithFields(log.Fields{
 			"path":  dirname,
 			"error": err,
 		}).Error("Cannot list directory.")
-		return
+		return err
 	}
 
-	envs = make(map[string]string)
+	daemontools := options.EnvDir.Mode == opts.EnvDirModeDaemontools
 	for _, item := range files {
-		if item.IsDir() {
+		if daemontools && strings.HasPrefix(item.Name(), ".") {
 			log.WithFields(log.Fields{
 				"dirname": dirname,
 				"name":    item.Name(),
-			}).Debug("Skip directory.")
+			}).Debug("Skip dot-file.")
+			continue
+		}
+
+		path := filepath.Join(dirname, item.Name())
+		itemPath := make([]string, len(prefix), len(prefix)+1)
+		copy(itemPath, prefix)
+		itemPath = append(itemPath, item.Name())
+
+		if item.IsDir() {
+			if !options.EnvDir.Recursive {
+				log.WithFields(log.Fields{
+					"dirname": dirname,
+					"name":    item.Name(),
+				}).Debug("Skip directory.")
+				continue
+			}
+
+			if err = readEnvDir(path, itemPath, options, envs); err != nil {
+				return err
+			}
 			continue
 		}
 
+		name := options.Naming.Compose(itemPath)
+		if _, ok := envs[name]; ok {
+			return fmt.Errorf("Name collision for %s in %s", name, dirname)
+		}
+
 		if item.Size() == 0 {
-			log.WithFields(log.Fields{
-				"dirname": dirname,
-				"name":    item.Name(),
-			}).Debug("Set to empty string because filesize is 0.")
-			envs[item.Name()] = ""
+			if daemontools {
+				log.WithFields(log.Fields{
+					"dirname": dirname,
+					"name":    item.Name(),
+				}).Debug("Remove variable because filesize is 0.")
+				envs[name] = unsetValue
+			} else {
+				log.WithFields(log.Fields{
+					"dirname": dirname,
+					"name":    item.Name(),
+				}).Debug("Set to empty string because filesize is 0.")
+				envs[name] = ""
+			}
 			continue
 		}
 
-		path := filepath.Join(dirname, item.Name())
 		content, err := ioutil.ReadFile(path)
 		if err != nil {
 			log.WithFields(log.Fields{
@@ -171,8 +472,68 @@ func configFormatEnvDirParser(dirname string) (envs map[string]string, err error
 			continue
 		}
 
-		envs[item.Name()] = strings.TrimSpace(string(content))
+		if daemontools {
+			envs[name] = readEnvDirValue(content)
+		} else {
+			envs[name] = strings.TrimSpace(string(content))
+		}
+	}
+
+	return nil
+}
+
+// listEnvDirs returns the list of nested directories of envdir which are
+// read by the parser.
+func listEnvDirs(dirname string, options *opts.Options) (dirs []string) {
+	files, err := ioutil.ReadDir(dirname)
+	if err != nil {
+		return
+	}
+
+	for _, item := range files {
+		if !item.IsDir() {
+			continue
+		}
+		if options.EnvDir.Mode == opts.EnvDirModeDaemontools && strings.HasPrefix(item.Name(), ".") {
+			continue
+		}
+
+		path := filepath.Join(dirname, item.Name())
+		dirs = append(dirs, path)
+		dirs = append(dirs, listEnvDirs(path, options)...)
 	}
 
 	return
 }
+
+// readEnvDirValue reads the value of variable like envdir of daemontools
+// does: only the first line is used, trailing spaces and tabs are trimmed
+// and NUL bytes are converted into newlines.
+func readEnvDirValue(content []byte) string {
+	if idx := bytes.IndexByte(content, '\n'); idx >= 0 {
+		content = content[:idx]
+	}
+	content = bytes.TrimRight(content, " \t")
+
+	return string(bytes.Replace(content, []byte{0}, []byte{'\n'}, -1))
+}
+
+// configFormatDotEnvParser parses .env files.
+func configFormatDotEnvParser(filename string, options *opts.Options) (map[string]string, error) {
+	return parseConfigFile(filename, options, parseDotEnvContent)
+}
+
+// parseDotEnvContent parses the content of .env file.
+func parseDotEnvContent(content []byte, options *opts.Options) (map[string]string, error) {
+	return parseDotEnv(string(content))
+}
+
+// configFormatPropertiesParser parses Java properties files.
+func configFormatPropertiesParser(filename string, options *opts.Options) (map[string]string, error) {
+	return parseConfigFile(filename, options, parsePropertiesContent)
+}
+
+// parsePropertiesContent parses the content of Java properties file.
+func parsePropertiesContent(content []byte, options *opts.Options) (map[string]string, error) {
+	return parseProperties(string(content), options)
+}
@@ -39,6 +39,9 @@ func TestGetParser(t *testing.T) {
 	assertFuncEquals(t, getParser(opts.ConfigFormatYAML), configFormatYAMLParser)
 	assertFuncEquals(t, getParser(opts.ConfigFormatINI), configFormatINIParser)
 	assertFuncEquals(t, getParser(opts.ConfigFormatEnvDir), configFormatEnvDirParser)
+	assertFuncEquals(t, getParser(opts.ConfigFormatDotEnv), configFormatDotEnvParser)
+	assertFuncEquals(t, getParser(opts.ConfigFormatTOML), configFormatTOMLParser)
+	assertFuncEquals(t, getParser(opts.ConfigFormatProperties), configFormatPropertiesParser)
 	assertFuncEquals(t, getParser(0xFF), configFormatNoneParser)
 }
 
@@ -54,7 +57,7 @@ func TestConfigFormatJSONOk(t *testing.T) {
 	`
 
 	fileName := getFileNameWithContent(data)
-	result, err := configFormatJSONParser(fileName)
+	result, err := configFormatJSONParser(fileName, createOptions())
 
 	assert.Nil(t, err)
 	assert.Equal(t, len(result), 5)
@@ -67,7 +70,7 @@ func TestConfigFormatJSONOk(t *testing.T) {
 
 func TestConfigFormatJSONEmpty(t *testing.T) {
 	fileName := getFileNameWithContent("")
-	_, err := configFormatJSONParser(fileName)
+	_, err := configFormatJSONParser(fileName, createOptions())
 
 	assert.NotNil(t, err)
 }
@@ -80,7 +83,7 @@ func TestConfigFormatJSONCorrupted(t *testing.T) {
 	`
 
 	fileName := getFileNameWithContent(data)
-	_, err := configFormatJSONParser(fileName)
+	_, err := configFormatJSONParser(fileName, createOptions())
 
 	assert.NotNil(t, err)
 }
@@ -88,12 +91,12 @@ func TestConfigFormatJSONCorrupted(t *testing.T) {
 func TestConfigFormatJSONIncorrectValue(t *testing.T) {
 	const data = `
 	{
-		"hello": []
+		"hello": [[1, 2]]
 	}
 	`
 
 	fileName := getFileNameWithContent(data)
-	_, err := configFormatJSONParser(fileName)
+	_, err := configFormatJSONParser(fileName, createOptions())
 
 	assert.NotNil(t, err)
 }
@@ -107,7 +110,7 @@ float_as_float: 1.0001
 `
 
 	fileName := getFileNameWithContent(data)
-	result, err := configFormatYAMLParser(fileName)
+	result, err := configFormatYAMLParser(fileName, createOptions())
 
 	assert.Nil(t, err)
 	assert.Equal(t, len(result), 5)
@@ -120,7 +123,7 @@ float_as_float: 1.0001
 
 func TestConfigFormatYAMLEmpty(t *testing.T) {
 	fileName := getFileNameWithContent("")
-	_, err := configFormatYAMLParser(fileName)
+	_, err := configFormatYAMLParser(fileName, createOptions())
 
 	assert.Nil(t, err)
 }
@@ -133,7 +136,7 @@ func TestConfigFormatYAMLCorrupted(t *testing.T) {
 	`
 
 	fileName := getFileNameWithContent(data)
-	_, err := configFormatYAMLParser(fileName)
+	_, err := configFormatYAMLParser(fileName, createOptions())
 
 	assert.NotNil(t, err)
 }
@@ -146,7 +149,7 @@ toot-toot:
 `
 
 	fileName := getFileNameWithContent(data)
-	_, err := configFormatJSONParser(fileName)
+	_, err := configFormatJSONParser(fileName, createOptions())
 
 	assert.NotNil(t, err)
 }
@@ -160,7 +163,7 @@ foo = bar
 bar =   baaz`
 
 	fileName := getFileNameWithContent(data)
-	result, err := configFormatINIParser(fileName)
+	result, err := configFormatINIParser(fileName, createOptions())
 
 	assert.Nil(t, err)
 	assert.Equal(t, len(result), 3)
@@ -173,7 +176,7 @@ func TestConfigFormatINIFail(t *testing.T) {
 	const data = `[somesec`
 
 	fileName := getFileNameWithContent(data)
-	_, err := configFormatINIParser(fileName)
+	_, err := configFormatINIParser(fileName, createOptions())
 
 	assert.NotNil(t, err)
 }
@@ -182,7 +185,7 @@ func TestConfigFormatNoSections(t *testing.T) {
 	const data = "t = 2"
 
 	fileName := getFileNameWithContent(data)
-	result, err := configFormatINIParser(fileName)
+	result, err := configFormatINIParser(fileName, createOptions())
 
 	assert.Nil(t, err)
 	assert.Equal(t, len(result), 1)
@@ -190,7 +193,7 @@ func TestConfigFormatNoSections(t *testing.T) {
 }
 
 func TestConfigFormatEnvDirParserNoDir(t *testing.T) {
-	_, err := configFormatEnvDirParser("WTF")
+	_, err := configFormatEnvDirParser("WTF", createOptions())
 
 	assert.NotNil(t, err)
 }
@@ -202,7 +205,7 @@ func TestConfigFormatEnvDirParserCorrectFiles(t *testing.T) {
 	createEnvDirVariable(tempDir, "hello", "world")
 	createEnvDirVariable(tempDir, "foo", "bar")
 
-	result, err := configFormatEnvDirParser(tempDir)
+	result, err := configFormatEnvDirParser(tempDir, createOptions())
 
 	assert.Nil(t, err)
 	assert.Equal(t, len(result), 2)
@@ -216,13 +219,65 @@ func TestConfigFormatEnvDirParserEmptyFile(t *testing.T) {
 
 	createEnvDirVariable(tempDir, "hello", "")
 
-	result, err := configFormatEnvDirParser(tempDir)
+	result, err := configFormatEnvDirParser(tempDir, createOptions())
+
+	assert.Nil(t, err)
+	assert.Equal(t, len(result), 1)
+	assert.Equal(t, result["hello"], unsetValue)
+}
+
+func TestConfigFormatEnvDirParserEmptyFileCompat(t *testing.T) {
+	tempDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(tempDir)
+
+	createEnvDirVariable(tempDir, "hello", "")
+
+	options := createOptions()
+	options.EnvDir.Mode = opts.EnvDirModeCompat
+	result, err := configFormatEnvDirParser(tempDir, options)
 
 	assert.Nil(t, err)
 	assert.Equal(t, len(result), 1)
 	assert.Equal(t, result["hello"], "")
 }
 
+func TestConfigFormatEnvDirParserDaemontools(t *testing.T) {
+	tempDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(tempDir)
+
+	createEnvDirVariable(tempDir, "first", "  line \t\nsecond line\n")
+	createEnvDirVariable(tempDir, "nul", "one\x00two\n")
+	createEnvDirVariable(tempDir, "blank", "\n")
+	createEnvDirVariable(tempDir, ".hidden", "value")
+
+	result, err := configFormatEnvDirParser(tempDir, createOptions())
+
+	assert.Nil(t, err)
+	assert.Equal(t, result, map[string]string{
+		"first": "  line",
+		"nul":   "one\ntwo",
+		"blank": "",
+	})
+}
+
+func TestConfigFormatEnvDirParserCompat(t *testing.T) {
+	tempDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(tempDir)
+
+	createEnvDirVariable(tempDir, "first", "  line \t\nsecond line\n")
+	createEnvDirVariable(tempDir, ".hidden", "value")
+
+	options := createOptions()
+	options.EnvDir.Mode = opts.EnvDirModeCompat
+	result, err := configFormatEnvDirParser(tempDir, options)
+
+	assert.Nil(t, err)
+	assert.Equal(t, result, map[string]string{
+		"first":   "line \t\nsecond line",
+		".hidden": "value",
+	})
+}
+
 func TestConfigFormatEnvDirParserSkipDirs(t *testing.T) {
 	tempDir, _ := ioutil.TempDir("", "")
 	defer os.RemoveAll(tempDir)
@@ -230,8 +285,544 @@ func TestConfigFormatEnvDirParserSkipDirs(t *testing.T) {
 	path := filepath.Join(tempDir, "WTF")
 	os.Mkdir(path, os.FileMode(0666))
 
-	result, err := configFormatEnvDirParser(tempDir)
+	result, err := configFormatEnvDirParser(tempDir, createOptions())
+
+	assert.Nil(t, err)
+	assert.Equal(t, len(result), 0)
+}
+
+func TestConfigFormatDotEnvOk(t *testing.T) {
+	const data = `# comment
+hello=world
+export foo = bar
+  export   spaced=value
+
+complex=1=1
+empty=
+commented=value # comment
+hash=value#not-a-comment
+`
+
+	fileName := getFileNameWithContent(data)
+	result, err := configFormatDotEnvParser(fileName, createOptions())
+
+	assert.Nil(t, err)
+	assert.Equal(t, len(result), 7)
+	assert.Equal(t, result["hello"], "world")
+	assert.Equal(t, result["foo"], "bar")
+	assert.Equal(t, result["spaced"], "value")
+	assert.Equal(t, result["complex"], "1=1")
+	assert.Equal(t, result["empty"], "")
+	assert.Equal(t, result["commented"], "value")
+	assert.Equal(t, result["hash"], "value#not-a-comment")
+}
+
+func TestConfigFormatDotEnvQuotes(t *testing.T) {
+	const data = `single='it is "quoted" \n # here'
+double="tab\there \"quote\" \\ \$HOME"
+unknown="\q"
+comment="value" # comment
+`
+
+	fileName := getFileNameWithContent(data)
+	result, err := configFormatDotEnvParser(fileName, createOptions())
+
+	assert.Nil(t, err)
+	assert.Equal(t, len(result), 4)
+	assert.Equal(t, result["single"], `it is "quoted" \n # here`)
+	assert.Equal(t, result["double"], "tab\there \"quote\" \\ $HOME")
+	assert.Equal(t, result["unknown"], `\q`)
+	assert.Equal(t, result["comment"], "value")
+}
+
+func TestConfigFormatDotEnvMultiLine(t *testing.T) {
+	const data = `key="-----BEGIN-----
+  line
+-----END-----"
+joined="one \
+two"
+after=1
+`
+
+	fileName := getFileNameWithContent(data)
+	result, err := configFormatDotEnvParser(fileName, createOptions())
+
+	assert.Nil(t, err)
+	assert.Equal(t, len(result), 3)
+	assert.Equal(t, result["key"], "-----BEGIN-----\n  line\n-----END-----")
+	assert.Equal(t, result["joined"], "one two")
+	assert.Equal(t, result["after"], "1")
+}
+
+func TestConfigFormatDotEnvFail(t *testing.T) {
+	for _, data := range []string{
+		"novalue",
+		"1abc=1",
+		"key=\"unterminated\n",
+		"key='unterminated",
+		"key=\"value\" garbage",
+	} {
+		fileName := getFileNameWithContent(data)
+		_, err := configFormatDotEnvParser(fileName, createOptions())
+
+		assert.NotNil(t, err)
+	}
+}
+
+func TestConfigFormatDotEnvNoFile(t *testing.T) {
+	_, err := configFormatDotEnvParser("WTF", createOptions())
+
+	assert.NotNil(t, err)
+}
+
+func TestConfigFormatTOMLOk(t *testing.T) {
+	const data = `hello = "world"
+key = 'value'
+int = 1
+float_ceiled = 1.0
+float_as_float = 1.0001
+date = 1979-05-27T07:32:00Z
+`
+
+	fileName := getFileNameWithContent(data)
+	result, err := configFormatTOMLParser(fileName, createOptions())
+
+	assert.Nil(t, err)
+	assert.Equal(t, len(result), 6)
+	assert.Equal(t, result["hello"], "world")
+	assert.Equal(t, result["key"], "value")
+	assert.Equal(t, result["int"], "1")
+	assert.Equal(t, result["float_ceiled"], "1")
+	assert.Equal(t, result["float_as_float"], "1.0001")
+	assert.Equal(t, result["date"], "1979-05-27T07:32:00Z")
+}
+
+func TestConfigFormatTOMLEmpty(t *testing.T) {
+	fileName := getFileNameWithContent("")
+	result, err := configFormatTOMLParser(fileName, createOptions())
 
 	assert.Nil(t, err)
 	assert.Equal(t, len(result), 0)
 }
+
+func TestConfigFormatTOMLCorrupted(t *testing.T) {
+	const data = `hello = "`
+
+	fileName := getFileNameWithContent(data)
+	_, err := configFormatTOMLParser(fileName, createOptions())
+
+	assert.NotNil(t, err)
+}
+
+func TestConfigFormatTOMLIncorrectValue(t *testing.T) {
+	const data = `hello = [[1, 2]]
+`
+
+	fileName := getFileNameWithContent(data)
+	_, err := configFormatTOMLParser(fileName, createOptions())
+
+	assert.NotNil(t, err)
+}
+
+func TestConfigFormatJSONNested(t *testing.T) {
+	const data = `
+	{
+		"hello": "world",
+		"database": {
+			"host": "localhost",
+			"port": 5432,
+			"replica": {
+				"host": "remote"
+			}
+		}
+	}
+	`
+
+	fileName := getFileNameWithContent(data)
+	result, err := configFormatJSONParser(fileName, createOptions())
+
+	assert.Nil(t, err)
+	assert.Equal(t, len(result), 4)
+	assert.Equal(t, result["hello"], "world")
+	assert.Equal(t, result["DATABASE_HOST"], "localhost")
+	assert.Equal(t, result["DATABASE_PORT"], "5432")
+	assert.Equal(t, result["DATABASE_REPLICA_HOST"], "remote")
+}
+
+func TestConfigFormatJSONNestedCustomNaming(t *testing.T) {
+	const data = `{"Database": {"Host": "localhost"}}`
+
+	options := createOptions()
+	options.Naming = opts.Naming{Separator: "__", Case: opts.NameCaseKeep}
+
+	fileName := getFileNameWithContent(data)
+	result, err := configFormatJSONParser(fileName, options)
+
+	assert.Nil(t, err)
+	assert.Equal(t, len(result), 1)
+	assert.Equal(t, result["Database__Host"], "localhost")
+
+	options.Naming = opts.Naming{Separator: ".", Case: opts.NameCaseLower}
+	result, err = configFormatJSONParser(fileName, options)
+
+	assert.Nil(t, err)
+	assert.Equal(t, len(result), 1)
+	assert.Equal(t, result["database.host"], "localhost")
+}
+
+func TestConfigFormatJSONNestedCollision(t *testing.T) {
+	const data = `{"DATABASE_HOST": "1", "database": {"host": "2"}}`
+
+	fileName := getFileNameWithContent(data)
+	_, err := configFormatJSONParser(fileName, createOptions())
+
+	assert.NotNil(t, err)
+}
+
+func TestConfigFormatYAMLNested(t *testing.T) {
+	const data = `hello: world
+database:
+  host: localhost
+  port: 5432
+`
+
+	fileName := getFileNameWithContent(data)
+	result, err := configFormatYAMLParser(fileName, createOptions())
+
+	assert.Nil(t, err)
+	assert.Equal(t, len(result), 3)
+	assert.Equal(t, result["hello"], "world")
+	assert.Equal(t, result["DATABASE_HOST"], "localhost")
+	assert.Equal(t, result["DATABASE_PORT"], "5432")
+}
+
+func TestConfigFormatTOMLTables(t *testing.T) {
+	const data = `hello = "world"
+
+[database]
+host = "localhost"
+port = 5432
+
+[database.replica]
+host = "remote"
+`
+
+	fileName := getFileNameWithContent(data)
+	result, err := configFormatTOMLParser(fileName, createOptions())
+
+	assert.Nil(t, err)
+	assert.Equal(t, len(result), 4)
+	assert.Equal(t, result["hello"], "world")
+	assert.Equal(t, result["DATABASE_HOST"], "localhost")
+	assert.Equal(t, result["DATABASE_PORT"], "5432")
+	assert.Equal(t, result["DATABASE_REPLICA_HOST"], "remote")
+}
+
+func TestConfigFormatJSONBooleans(t *testing.T) {
+	const data = `{"yes": true, "no": false}`
+
+	fileName := getFileNameWithContent(data)
+	result, err := configFormatJSONParser(fileName, createOptions())
+
+	assert.Nil(t, err)
+	assert.Equal(t, len(result), 2)
+	assert.Equal(t, result["yes"], "true")
+	assert.Equal(t, result["no"], "false")
+}
+
+func TestConfigFormatJSONNullUnset(t *testing.T) {
+	const data = `{"hello": "world", "nothing": null}`
+
+	fileName := getFileNameWithContent(data)
+	result, err := configFormatJSONParser(fileName, createOptions())
+
+	assert.Nil(t, err)
+	assert.Equal(t, len(result), 2)
+	assert.Equal(t, result["hello"], "world")
+	assert.Equal(t, result["nothing"], unsetValue)
+}
+
+func TestConfigFormatJSONNullEmpty(t *testing.T) {
+	const data = `{"hello": "world", "nothing": null}`
+
+	options := createOptions()
+	options.Values.Null = opts.NullModeEmpty
+
+	fileName := getFileNameWithContent(data)
+	result, err := configFormatJSONParser(fileName, options)
+
+	assert.Nil(t, err)
+	assert.Equal(t, len(result), 2)
+	assert.Equal(t, result["nothing"], "")
+}
+
+func TestConfigFormatJSONArrayJoin(t *testing.T) {
+	const data = `{"hosts": ["a", "b", 1, true, null], "empty": []}`
+
+	fileName := getFileNameWithContent(data)
+	result, err := configFormatJSONParser(fileName, createOptions())
+
+	assert.Nil(t, err)
+	assert.Equal(t, len(result), 2)
+	assert.Equal(t, result["hosts"], "a,b,1,true,")
+	assert.Equal(t, result["empty"], "")
+
+	options := createOptions()
+	options.Values.ArraySeparator = ":"
+	result, err = configFormatJSONParser(fileName, options)
+
+	assert.Nil(t, err)
+	assert.Equal(t, result["hosts"], "a:b:1:true:")
+}
+
+func TestConfigFormatJSONArrayJoinNested(t *testing.T) {
+	const data = `{"hosts": [{"host": "a"}]}`
+
+	fileName := getFileNameWithContent(data)
+	_, err := configFormatJSONParser(fileName, createOptions())
+
+	assert.NotNil(t, err)
+}
+
+func TestConfigFormatJSONArrayJSON(t *testing.T) {
+	const data = `{"hosts": ["a", 1, true, null, [1], {"host": "b"}]}`
+
+	options := createOptions()
+	options.Values.Array = opts.ArrayModeJSON
+
+	fileName := getFileNameWithContent(data)
+	result, err := configFormatJSONParser(fileName, options)
+
+	assert.Nil(t, err)
+	assert.Equal(t, len(result), 1)
+	assert.Equal(t, result["hosts"], `["a",1,true,null,[1],{"host":"b"}]`)
+}
+
+func TestConfigFormatYAMLScalarsAndArrays(t *testing.T) {
+	const data = `enabled: yes
+nothing: ~
+hosts:
+  - a
+  - b
+nested:
+  - host: a
+`
+
+	options := createOptions()
+	options.Values.Array = opts.ArrayModeJSON
+
+	fileName := getFileNameWithContent(data)
+	result, err := configFormatYAMLParser(fileName, options)
+
+	assert.Nil(t, err)
+	assert.Equal(t, len(result), 4)
+	assert.Equal(t, result["enabled"], "true")
+	assert.Equal(t, result["nothing"], unsetValue)
+	assert.Equal(t, result["hosts"], `["a","b"]`)
+	assert.Equal(t, result["nested"], `[{"host":"a"}]`)
+}
+
+func TestConfigFormatTOMLArrayOfTables(t *testing.T) {
+	const data = `flag = true
+ports = [1, 2]
+
+[[servers]]
+host = "a"
+`
+
+	options := createOptions()
+	options.Values.Array = opts.ArrayModeJSON
+
+	fileName := getFileNameWithContent(data)
+	result, err := configFormatTOMLParser(fileName, options)
+
+	assert.Nil(t, err)
+	assert.Equal(t, len(result), 3)
+	assert.Equal(t, result["flag"], "true")
+	assert.Equal(t, result["ports"], "[1,2]")
+	assert.Equal(t, result["servers"], `[{"host":"a"}]`)
+}
+
+func TestConfigFormatINIMergeOrder(t *testing.T) {
+	const data = `key = global
+only = global
+
+[b]
+key = b
+
+[a]
+key = a`
+
+	fileName := getFileNameWithContent(data)
+	result, err := configFormatINIParser(fileName, createOptions())
+
+	assert.Nil(t, err)
+	assert.Equal(t, len(result), 2)
+	assert.Equal(t, result["key"], "b")
+	assert.Equal(t, result["only"], "global")
+}
+
+func TestConfigFormatINIPrefix(t *testing.T) {
+	const data = `host = global
+
+[db]
+host = localhost
+
+[cache]
+host = remote`
+
+	options := createOptions()
+	options.INI.Mode = opts.INIModePrefix
+
+	fileName := getFileNameWithContent(data)
+	result, err := configFormatINIParser(fileName, options)
+
+	assert.Nil(t, err)
+	assert.Equal(t, len(result), 3)
+	assert.Equal(t, result["host"], "global")
+	assert.Equal(t, result["DB_HOST"], "localhost")
+	assert.Equal(t, result["CACHE_HOST"], "remote")
+}
+
+func TestConfigFormatINISelect(t *testing.T) {
+	const data = `host = global
+port = 80
+
+[staging]
+host = staging
+debug = 1
+
+[production]
+host = production
+
+[local]
+debug = 0`
+
+	options := createOptions()
+	options.INI.Mode = opts.INIModeSelect
+	options.INI.Sections = []string{"staging", "local"}
+
+	fileName := getFileNameWithContent(data)
+	result, err := configFormatINIParser(fileName, options)
+
+	assert.Nil(t, err)
+	assert.Equal(t, len(result), 3)
+	assert.Equal(t, result["host"], "staging")
+	assert.Equal(t, result["port"], "80")
+	assert.Equal(t, result["debug"], "0")
+
+	options.INI.Sections = []string{}
+	result, err = configFormatINIParser(fileName, options)
+
+	assert.Nil(t, err)
+	assert.Equal(t, len(result), 2)
+	assert.Equal(t, result["host"], "global")
+}
+
+func TestConfigFormatINISelectAbsentSection(t *testing.T) {
+	const data = `host = global`
+
+	options := createOptions()
+	options.INI.Mode = opts.INIModeSelect
+	options.INI.Sections = []string{"production"}
+
+	fileName := getFileNameWithContent(data)
+	_, err := configFormatINIParser(fileName, options)
+
+	assert.NotNil(t, err)
+}
+
+func TestConfigFormatEnvDirParserRecursive(t *testing.T) {
+	tempDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(tempDir)
+
+	os.MkdirAll(filepath.Join(tempDir, "db", "replica"), os.FileMode(0777))
+	os.Mkdir(filepath.Join(tempDir, ".git"), os.FileMode(0777))
+	createEnvDirVariable(tempDir, "top", "value")
+	createEnvDirVariable(filepath.Join(tempDir, "db"), "host", "localhost")
+	createEnvDirVariable(filepath.Join(tempDir, "db", "replica"), "host", "replica")
+	createEnvDirVariable(filepath.Join(tempDir, ".git"), "HEAD", "master")
+
+	options := createOptions()
+	result, err := configFormatEnvDirParser(tempDir, options)
+	assert.Nil(t, err)
+	assert.Equal(t, result, map[string]string{"top": "value"})
+
+	options.EnvDir.Recursive = true
+	result, err = configFormatEnvDirParser(tempDir, options)
+	assert.Nil(t, err)
+	assert.Equal(t, result, map[string]string{
+		"top":             "value",
+		"DB_HOST":         "localhost",
+		"DB_REPLICA_HOST": "replica",
+	})
+
+	createEnvDirVariable(tempDir, "DB_HOST", "collision")
+	_, err = configFormatEnvDirParser(tempDir, options)
+	assert.NotNil(t, err)
+}
+
+func TestConfigFormatPropertiesOk(t *testing.T) {
+	fileName := getFileNameWithContent(`# comment
+! another comment
+db.host = localhost
+db.port:5432
+db.user  admin
+message = Hello, \
+          world
+path=c:\\temp\\dir
+unicode=\u0416\ud83d\ude00
+key\ with\ spaces = value
+empty=
+tabs=a\tb
+continued=first\
+
+next=value
+`)
+	defer os.Remove(fileName)
+
+	result, err := configFormatPropertiesParser(fileName, createOptions())
+
+	assert.Nil(t, err)
+	assert.Equal(t, result, map[string]string{
+		"db.host":         "localhost",
+		"db.port":         "5432",
+		"db.user":         "admin",
+		"message":         "Hello, world",
+		"path":            `c:\temp\dir`,
+		"unicode":         "Ж😀",
+		"key with spaces": "value",
+		"empty":           "",
+		"tabs":            "a\tb",
+		"continued":       "first",
+		"next":            "value",
+	})
+}
+
+func TestConfigFormatPropertiesConvertNames(t *testing.T) {
+	fileName := getFileNameWithContent("db.host=localhost\nname=app\n")
+	defer os.Remove(fileName)
+
+	options := createOptions()
+	options.Properties.ConvertNames = true
+	result, err := configFormatPropertiesParser(fileName, options)
+
+	assert.Nil(t, err)
+	assert.Equal(t, result, map[string]string{"DB_HOST": "localhost", "name": "app"})
+}
+
+func TestConfigFormatPropertiesFail(t *testing.T) {
+	fileName := getFileNameWithContent("key=\\u12\n")
+	defer os.Remove(fileName)
+
+	_, err := configFormatPropertiesParser(fileName, createOptions())
+	assert.NotNil(t, err)
+
+	collision := getFileNameWithContent("db.host=a\nDB_HOST=b\n")
+	defer os.Remove(collision)
+
+	options := createOptions()
+	options.Properties.ConvertNames = true
+	_, err = configFormatPropertiesParser(collision, options)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,145 @@
+// Package environment has a definition of Environment struct with parser.
+// This file contains printing of environment in different formats.
+package environment
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	opts "github.com/9seconds/guidedog/internal/options"
+)
+
+// lineFormatter formats a single variable as a line of printed
+// environment.
+type lineFormatter func(name string, value string) (string, error)
+
+// printedVariable is a variable with its origin for JSON output.
+type printedVariable struct {
+	Value  string `json:"value"`
+	Origin string `json:"origin"`
+}
+
+// Print writes current environment snapshot in the format defined by
+// Options.Print. Envdir format is written into Options.Print.Directory,
+// writer is not used in that case.
+func (env *Environment) Print(writer io.Writer) error {
+	variables := env.Variables()
+	origins := env.Origins()
+
+	names := sortedNames(variables)
+
+	switch env.Options.Print.Format {
+	case opts.PrintFormatExport:
+		return printLines(writer, names, variables, origins, env.Options.Print.Origins, formatExportLine)
+	case opts.PrintFormatDocker:
+		return printLines(writer, names, variables, origins, env.Options.Print.Origins, formatDockerLine)
+	case opts.PrintFormatSystemd:
+		return printLines(writer, names, variables, origins, env.Options.Print.Origins, formatSystemdLine)
+	case opts.PrintFormatJSON:
+		return printJSON(writer, variables, origins, env.Options.Print.Origins)
+	case opts.PrintFormatEnvDir:
+		return writeEnvDir(env.Options.Print.Directory, names, variables)
+	default:
+		return fmt.Errorf("Unknown print format %s", env.Options.Print.Format)
+	}
+}
+
+// printLines writes variables line by line. Origins are written as
+// comments before the variables.
+func printLines(writer io.Writer,
+	names []string,
+	variables map[string]string,
+	origins map[string]string,
+	withOrigins bool,
+	formatter lineFormatter) error {
+	for _, name := range names {
+		line, err := formatter(name, variables[name])
+		if err != nil {
+			return err
+		}
+
+		if withOrigins {
+			if _, err = fmt.Fprintf(writer, "# origin: %s\n", origins[name]); err != nil {
+				return err
+			}
+		}
+		if _, err = fmt.Fprintln(writer, line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// formatExportLine formats variable as shell export line.
+func formatExportLine(name string, value string) (string, error) {
+	return "export " + name + "='" + strings.Replace(value, "'", `'\''`, -1) + "'", nil
+}
+
+// formatDockerLine formats variable as a line of Docker --env-file. Such
+// files have no quoting so multi-line values are not supported.
+func formatDockerLine(name string, value string) (string, error) {
+	if strings.ContainsAny(value, "\r\n") {
+		return "", fmt.Errorf("Docker env-file cannot contain multi-line value of %s", name)
+	}
+
+	return name + "=" + value, nil
+}
+
+// formatSystemdLine formats variable as a line of systemd
+// EnvironmentFile.
+func formatSystemdLine(name string, value string) (string, error) {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`")
+
+	return name + `="` + replacer.Replace(value) + `"`, nil
+}
+
+// printJSON writes variables as JSON object. If origins are required,
+// values are objects with value and origin fields.
+func printJSON(writer io.Writer, variables map[string]string, origins map[string]string, withOrigins bool) error {
+	var data interface{} = variables
+
+	if withOrigins {
+		printed := make(map[string]printedVariable, len(variables))
+		for name, value := range variables {
+			printed[name] = printedVariable{Value: value, Origin: origins[name]}
+		}
+		data = printed
+	}
+
+	encoded, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(writer, string(encoded))
+
+	return err
+}
+
+// writeEnvDir writes variables into directory in a way envdir of
+// daemontools reads them back: newlines are written as NUL bytes and
+// empty values are written as empty lines.
+func writeEnvDir(dirname string, names []string, variables map[string]string) error {
+	if err := os.MkdirAll(dirname, os.FileMode(0700)); err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		if strings.ContainsAny(name, "/=") || strings.HasPrefix(name, ".") {
+			return fmt.Errorf("Variable %s cannot be written into envdir", name)
+		}
+
+		content := strings.Replace(variables[name], "\n", "\x00", -1) + "\n"
+		if err := ioutil.WriteFile(filepath.Join(dirname, name), []byte(content), os.FileMode(0600)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
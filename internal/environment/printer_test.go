@@ -0,0 +1,113 @@
+package environment
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	assert "github.com/stretchr/testify/assert"
+
+	opts "github.com/9seconds/guidedog/internal/options"
+)
+
+func createPrintEnvironment(format opts.PrintFormat, origins bool) *Environment {
+	options := createOptions()
+	options.Inheritance.Clean = true
+	options.Envs = map[string]string{"QUOTE": "it's \"$HOME\""}
+	options.Print = opts.Print{Format: format, Origins: origins}
+
+	env, _ := NewEnvironment(options)
+
+	return env
+}
+
+func TestPrintExport(t *testing.T) {
+	var buffer bytes.Buffer
+
+	err := createPrintEnvironment(opts.PrintFormatExport, true).Print(&buffer)
+	assert.Nil(t, err)
+	assert.Equal(t, buffer.String(), "# origin: env\nexport QUOTE='it'\\''s \"$HOME\"'\n")
+}
+
+func TestPrintDocker(t *testing.T) {
+	var buffer bytes.Buffer
+
+	env := createPrintEnvironment(opts.PrintFormatDocker, false)
+	assert.Nil(t, env.Print(&buffer))
+	assert.Equal(t, buffer.String(), "QUOTE=it's \"$HOME\"\n")
+
+	env.Options.Envs["MULTI"] = "a\nb"
+	env.Update()
+	assert.NotNil(t, env.Print(&buffer))
+}
+
+func TestPrintSystemd(t *testing.T) {
+	var buffer bytes.Buffer
+
+	err := createPrintEnvironment(opts.PrintFormatSystemd, false).Print(&buffer)
+	assert.Nil(t, err)
+	assert.Equal(t, buffer.String(), "QUOTE=\"it's \\\"\\$HOME\\\"\"\n")
+}
+
+func TestPrintJSON(t *testing.T) {
+	var buffer bytes.Buffer
+
+	err := createPrintEnvironment(opts.PrintFormatJSON, false).Print(&buffer)
+	assert.Nil(t, err)
+	assert.JSONEq(t, buffer.String(), `{"QUOTE": "it's \"$HOME\""}`)
+
+	buffer.Reset()
+	err = createPrintEnvironment(opts.PrintFormatJSON, true).Print(&buffer)
+	assert.Nil(t, err)
+	assert.JSONEq(t, buffer.String(), `{"QUOTE": {"value": "it's \"$HOME\"", "origin": "env"}}`)
+}
+
+func TestPrintEnvDir(t *testing.T) {
+	tempDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(tempDir)
+
+	env := createPrintEnvironment(opts.PrintFormatEnvDir, false)
+	env.Options.Envs["MULTI"] = "a\nb"
+	env.Options.Envs["EMPTY"] = ""
+	env.Update()
+	env.Options.Print.Directory = filepath.Join(tempDir, "env")
+
+	assert.Nil(t, env.Print(nil))
+
+	options := createOptions()
+	options.ConfigSources = []opts.ConfigSource{{Format: opts.ConfigFormatEnvDir, Path: env.Options.Print.Directory}}
+	options.Inheritance.Clean = true
+	parsed, err := NewEnvironment(options)
+	assert.Nil(t, err)
+	assert.Equal(t, parsed.Variables(), env.Variables())
+}
+
+func TestOrigins(t *testing.T) {
+	tempDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(tempDir)
+
+	filename := createTempJSON(`{"FROM_CONFIG": "1", "SECRET_FILE": "` + filepath.Join(tempDir, "secret") + `"}`)
+	defer os.Remove(filename)
+	ioutil.WriteFile(filepath.Join(tempDir, "secret"), []byte("value"), os.FileMode(0600))
+
+	options := createOptions()
+	options.ConfigSources = createJSONSources(filename)
+	options.Envs = map[string]string{"PRESET": "2"}
+	options.SecretFiles.Enabled = true
+	options.Inheritance.Allow = []string{"HOME"}
+	options.Schema = opts.Schema{Variables: []opts.SchemaVariable{{Name: "DEFAULT", Default: "3", HasDefault: true}}}
+
+	env, err := NewEnvironment(options)
+	assert.Nil(t, err)
+
+	origins := env.Origins()
+	assert.Equal(t, origins["FROM_CONFIG"], "json:"+filename)
+	assert.Equal(t, origins["PRESET"], OriginPredefined)
+	assert.Equal(t, origins["SECRET"], OriginSecretFile+":"+filepath.Join(tempDir, "secret"))
+	assert.Equal(t, origins["DEFAULT"], OriginSchemaDefault)
+	if _, ok := env.Variables()["HOME"]; ok {
+		assert.Equal(t, origins["HOME"], OriginInherited)
+	}
+}
@@ -0,0 +1,171 @@
+// Package environment has a definition of Environment struct with parser.
+// This file contains a lexer for Java properties files. For the parser
+// itself please check parsers.go file.
+package environment
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	opts "github.com/9seconds/guidedog/internal/options"
+)
+
+// propertiesWhitespace defines whitespace characters of properties files.
+const propertiesWhitespace = " \t\f"
+
+// propertiesEscapes defines escape sequences of properties files. Other
+// escaped characters are taken as is.
+var propertiesEscapes = map[byte]rune{
+	't': '\t',
+	'n': '\n',
+	'r': '\r',
+	'f': '\f',
+}
+
+// parseProperties parses the content of Java properties file. It supports
+// '=', ':' or whitespace separators, backslash line continuations, \uXXXX
+// escapes and '#' and '!' comments. If options require, keys like db.host
+// are converted into names according to Naming.
+func parseProperties(content string, options *opts.Options) (envs map[string]string, err error) {
+	envs = make(map[string]string)
+	keys := make(map[string]string)
+
+	for lineNumber, line := range readPropertiesLines(content) {
+		key, value, err := splitPropertiesLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("Entry %d: %v", lineNumber+1, err)
+		}
+
+		name := key
+		if options.Properties.ConvertNames {
+			name = options.Naming.Compose(strings.Split(key, opts.PropertiesKeySeparator))
+		}
+
+		if otherKey, ok := keys[name]; ok && otherKey != key {
+			return nil, fmt.Errorf("Name collision for %s: keys %s and %s", name, otherKey, key)
+		}
+		keys[name] = key
+		envs[name] = value
+	}
+
+	return
+}
+
+// readPropertiesLines returns logical lines of properties file: comments
+// and blank lines are skipped, continued lines are joined.
+func readPropertiesLines(content string) []string {
+	content = strings.Replace(content, "\r\n", "\n", -1)
+	naturalLines := strings.Split(strings.Replace(content, "\r", "\n", -1), "\n")
+	lines := make([]string, 0, len(naturalLines))
+
+	for idx := 0; idx < len(naturalLines); idx++ {
+		line := strings.TrimLeft(naturalLines[idx], propertiesWhitespace)
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+
+		for isContinuedPropertiesLine(line) {
+			line = line[:len(line)-1]
+			idx++
+			if idx >= len(naturalLines) {
+				break
+			}
+			line += strings.TrimLeft(naturalLines[idx], propertiesWhitespace)
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+// isContinuedPropertiesLine checks if line ends with odd number of
+// backslashes.
+func isContinuedPropertiesLine(line string) bool {
+	count := 0
+	for idx := len(line) - 1; idx >= 0 && line[idx] == '\\'; idx-- {
+		count++
+	}
+
+	return count%2 == 1
+}
+
+// splitPropertiesLine splits logical line into unescaped key and value.
+func splitPropertiesLine(line string) (key string, value string, err error) {
+	end := 0
+	for end < len(line) {
+		char := line[end]
+		if char == '\\' {
+			end += 2
+			continue
+		}
+		if char == '=' || char == ':' || strings.IndexByte(propertiesWhitespace, char) >= 0 {
+			break
+		}
+		end++
+	}
+	if end > len(line) {
+		end = len(line)
+	}
+
+	rest := strings.TrimLeft(line[end:], propertiesWhitespace)
+	if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+		rest = strings.TrimLeft(rest[1:], propertiesWhitespace)
+	}
+
+	if key, err = unescapeProperties(line[:end]); err != nil {
+		return
+	}
+	value, err = unescapeProperties(rest)
+
+	return
+}
+
+// unescapeProperties processes escape sequences of properties files.
+func unescapeProperties(text string) (string, error) {
+	var buffer bytes.Buffer
+	units := make([]uint16, 0)
+
+	flushUnits := func() {
+		for _, char := range utf16.Decode(units) {
+			buffer.WriteRune(char)
+		}
+		units = units[:0]
+	}
+
+	for idx := 0; idx < len(text); idx++ {
+		char := text[idx]
+		if char != '\\' || idx+1 == len(text) {
+			flushUnits()
+			buffer.WriteByte(char)
+			continue
+		}
+
+		idx++
+		if text[idx] == 'u' {
+			if idx+5 > len(text) {
+				return "", fmt.Errorf("malformed \\uXXXX escape in %s", text)
+			}
+			unit, err := strconv.ParseUint(text[idx+1:idx+5], 16, 16)
+			if err != nil {
+				return "", fmt.Errorf("malformed \\uXXXX escape in %s", text)
+			}
+			units = append(units, uint16(unit))
+			idx += 4
+			continue
+		}
+
+		flushUnits()
+		if escaped, ok := propertiesEscapes[text[idx]]; ok {
+			buffer.WriteRune(escaped)
+		} else {
+			buffer.WriteByte(text[idx])
+		}
+	}
+	flushUnits()
+
+	return buffer.String(), nil
+}
@@ -0,0 +1,53 @@
+// Package environment has a definition of Environment struct with parser.
+// This file contains validation of environment against the schema.
+package environment
+
+import (
+	"fmt"
+	"strings"
+
+	opts "github.com/9seconds/guidedog/internal/options"
+)
+
+// ValidationError is returned if environment does not match the schema.
+// It contains all found violations.
+type ValidationError struct {
+	Violations []string
+}
+
+func (ve *ValidationError) Error() string {
+	return "Environment does not match schema:\n  " + strings.Join(ve.Violations, "\n  ")
+}
+
+// validateEnvironment sets defaults for missing variables and checks the
+// environment against the schema. Returns ValidationError with all
+// violations if there are any.
+func validateEnvironment(variables map[string]string, origins map[string]string, schema opts.Schema) error {
+	violations := make([]string, 0)
+
+	for _, variable := range schema.Variables {
+		value, ok := variables[variable.Name]
+		if !ok && variable.HasDefault {
+			value, ok = variable.Default, true
+			variables[variable.Name] = value
+			origins[variable.Name] = OriginSchemaDefault
+		}
+
+		if !ok {
+			if variable.Required {
+				violations = append(violations, fmt.Sprintf("%s: is required", variable.Name))
+			}
+			continue
+		}
+
+		if err := variable.Check(value); err != nil {
+			violations = append(violations, fmt.Sprintf("%s: %v", variable.Name, err))
+		}
+	}
+
+	if len(violations) > 0 {
+		return &ValidationError{Violations: violations}
+	}
+
+	return nil
+}
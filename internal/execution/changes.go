@@ -0,0 +1,104 @@
+// Package execution contains all logic for execution of external commands
+// based on Environment struct.
+//
+// This file contains detection of changes in tracked paths and
+// environment.
+package execution
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// hashPaths returns content hashes of the given paths. Hash of directory
+// is built from the names and contents of its files and nested
+// directories. Missing paths have empty hashes.
+func hashPaths(paths []string) map[string]string {
+	hashes := make(map[string]string, len(paths))
+
+	for _, path := range paths {
+		if path != "" {
+			hashes[path] = hashPath(path)
+		}
+	}
+
+	return hashes
+}
+
+// hashPath returns content hash of the given path.
+func hashPath(path string) string {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+
+	hash := sha256.New()
+	if !stat.IsDir() {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return ""
+		}
+		hash.Write(content)
+
+		return hex.EncodeToString(hash.Sum(nil))
+	}
+
+	files, err := ioutil.ReadDir(path)
+	if err != nil {
+		return ""
+	}
+
+	for _, item := range files {
+		hash.Write([]byte(item.Name()))
+		if item.IsDir() {
+			hash.Write([]byte("/"))
+		} else {
+			hash.Write([]byte{0})
+		}
+		hash.Write([]byte(hashPath(filepath.Join(path, item.Name()))))
+		hash.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// changedKeys returns the sorted list of keys which are present only in
+// one of the maps or have different values. It is used to find changed
+// paths (by content hashes) and changed environment variables.
+func changedKeys(oldMap, newMap map[string]string) []string {
+	keys := make([]string, 0)
+
+	for key, value := range oldMap {
+		if newValue, ok := newMap[key]; !ok || newValue != value {
+			keys = append(keys, key)
+		}
+	}
+	for key := range newMap {
+		if _, ok := oldMap[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// filterPaths returns the paths which are present in the given list.
+func filterPaths(paths []string, allowed []string) []string {
+	filtered := make([]string, 0)
+
+	for _, path := range paths {
+		for _, allowedPath := range allowed {
+			if path == allowedPath {
+				filtered = append(filtered, path)
+				break
+			}
+		}
+	}
+
+	return filtered
+}
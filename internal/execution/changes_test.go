@@ -0,0 +1,94 @@
+package execution
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestHashPath(t *testing.T) {
+	tempDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(tempDir)
+
+	filename := filepath.Join(tempDir, "file")
+	nestedDir := filepath.Join(tempDir, "nested")
+	nestedFile := filepath.Join(nestedDir, "file")
+	missing := filepath.Join(tempDir, "missing")
+
+	ioutil.WriteFile(filename, []byte("content"), os.FileMode(0666))
+	os.Mkdir(nestedDir, os.FileMode(0777))
+	ioutil.WriteFile(nestedFile, []byte("content"), os.FileMode(0666))
+
+	testCases := []struct {
+		name    string
+		path    string
+		change  func()
+		changed bool
+	}{
+		{"chmod", filename, func() { os.Chmod(filename, os.FileMode(0600)) }, false},
+		{"touch", filename, func() { os.Chtimes(filename, time.Now(), time.Now().Add(time.Hour)) }, false},
+		{"same content", filename, func() { ioutil.WriteFile(filename, []byte("content"), os.FileMode(0666)) }, false},
+		{"new content", filename, func() { ioutil.WriteFile(filename, []byte("changed"), os.FileMode(0666)) }, true},
+		{"touch nested file", tempDir, func() { os.Chtimes(nestedFile, time.Now(), time.Now().Add(time.Hour)) }, false},
+		{"new content of nested file", tempDir, func() { ioutil.WriteFile(nestedFile, []byte("changed"), os.FileMode(0666)) }, true},
+		{"new file in directory", tempDir, func() { ioutil.WriteFile(filepath.Join(tempDir, "new"), []byte{}, os.FileMode(0666)) }, true},
+		{"missing", missing, func() {}, false},
+		{"created", missing, func() { ioutil.WriteFile(missing, []byte{}, os.FileMode(0666)) }, true},
+	}
+
+	for _, testCase := range testCases {
+		hash := hashPath(testCase.path)
+		testCase.change()
+		assert.Equal(t, hash != hashPath(testCase.path), testCase.changed, testCase.name)
+	}
+}
+
+func TestHashPathMissing(t *testing.T) {
+	assert.Equal(t, hashPath("/nonexistent/path"), "")
+	assert.Equal(t, hashPaths([]string{"/nonexistent/path", ""}), map[string]string{"/nonexistent/path": ""})
+}
+
+func TestChangedKeys(t *testing.T) {
+	testCases := []struct {
+		oldMap map[string]string
+		newMap map[string]string
+		keys   []string
+	}{
+		{map[string]string{}, map[string]string{}, []string{}},
+		{map[string]string{"A": "1"}, map[string]string{"A": "1"}, []string{}},
+		{map[string]string{}, map[string]string{"A": "1"}, []string{"A"}},
+		{map[string]string{"A": "1"}, map[string]string{}, []string{"A"}},
+		{map[string]string{"A": "1"}, map[string]string{"A": "2"}, []string{"A"}},
+		{map[string]string{"A": ""}, map[string]string{}, []string{"A"}},
+		{
+			map[string]string{"C": "1", "B": "1", "D": "1"},
+			map[string]string{"A": "1", "B": "2", "D": "1"},
+			[]string{"A", "B", "C"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, changedKeys(testCase.oldMap, testCase.newMap), testCase.keys)
+	}
+}
+
+func TestFilterPaths(t *testing.T) {
+	testCases := []struct {
+		paths    []string
+		allowed  []string
+		filtered []string
+	}{
+		{[]string{}, []string{"/a"}, []string{}},
+		{[]string{"/a"}, []string{}, []string{}},
+		{[]string{"/a", "/b", "/c"}, []string{"/c", "/a"}, []string{"/a", "/c"}},
+		{[]string{"/a/b"}, []string{"/a"}, []string{}},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, filterPaths(testCase.paths, testCase.allowed), testCase.filtered)
+	}
+}
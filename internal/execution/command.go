@@ -16,21 +16,39 @@ import (
 	log "github.com/Sirupsen/logrus"
 	term "github.com/docker/docker/pkg/term"
 	pty "github.com/kr/pty"
+
+	options "github.com/9seconds/guidedog/internal/options"
 )
 
 // command just a thin wrapper for the exec.Cmd which can restart
-// and do some addition niceties.
+// and do some addition niceties. The only Wait call is done in a separate
+// goroutine which sets exitStatus and closes done channel after the exit
+// of the process.
 type command struct {
-	cmd *exec.Cmd
+	cmd        *exec.Cmd
+	done       chan struct{}
+	exitStatus options.ExitStatus
 }
 
+// String returns the command line only: environment of the command may
+// contain secrets.
 func (c *command) String() string {
-	return fmt.Sprintf("%+v", c.cmd)
+	return fmt.Sprintf("%v", c.cmd.Args)
+}
+
+// Done returns the channel which is closed after the exit of the process.
+func (c *command) Done() <-chan struct{} {
+	return c.done
 }
 
 // Stopped checks if command stopped or not.
 func (c *command) Stopped() bool {
-	return c.cmd.ProcessState != nil
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
 }
 
 // ExitCode returns exit code of the command if it is stopped.
@@ -40,18 +58,18 @@ func (c *command) ExitCode() int {
 		return exitCodeStillRunning
 	}
 
-	waitStatus, ok := c.cmd.ProcessState.Sys().(syscall.WaitStatus)
-	if !ok {
-		log.Fatal("Cannot convert ProcessState to WaitStatus!")
-		return exitCodeInternalError
-	}
+	return c.exitStatus.Code
+}
 
-	exitCode := waitStatus.ExitStatus()
-	if exitCode < 0 {
-		exitCode = exitCodeInterrupt
+// ExitStatus returns exit code of the command and the signal which killed
+// it. Command has to be stopped.
+func (c *command) ExitStatus() options.ExitStatus {
+	if !c.Stopped() {
+		log.WithField("command", c).Warn("Command is still running!")
+		return options.ExitStatus{Code: exitCodeStillRunning}
 	}
 
-	return exitCode
+	return c.exitStatus
 }
 
 // Stop do what the name defines.
@@ -60,29 +78,60 @@ func (c *command) Stop(signal os.Signal, timeout time.Duration) {
 		return
 	}
 
-	gracefulTimerChannel := time.Tick(timeoutGracefulSignal)
-	killTimerChannel := time.After(timeout)
-
-	log.WithField("cmd", c.cmd).Info("Start stopping process.")
+	log.WithField("cmd", c).Info("Start stopping process.")
 	c.cmd.Process.Signal(signal)
 
-	for !c.Stopped() {
-		select {
-		case <-gracefulTimerChannel:
-			continue
-		case <-killTimerChannel:
-			log.Info("Graceful timeout expired, send kill signal")
-			c.cmd.Process.Signal(syscall.SIGKILL)
-		}
+	select {
+	case <-c.done:
+	case <-time.After(timeout):
+		log.Info("Graceful timeout expired, send kill signal")
+		c.cmd.Process.Signal(syscall.SIGKILL)
+		<-c.done
 	}
 }
 
-// newCommand returns new running command instance.
-func newCommand(commandToExecute []string, hasTTY bool) (commandToRun *command, err error) {
+// wait waits for the exit of the process and publishes it.
+func (c *command) wait() {
+	c.cmd.Wait()
+	c.exitStatus = getExitStatus(c.cmd.ProcessState)
+	close(c.done)
+}
+
+// getExitStatus converts the state of exited process into exit code and
+// signal which killed the process.
+func getExitStatus(state *os.ProcessState) (status options.ExitStatus) {
+	if state == nil {
+		status.Code = exitCodeInternalError
+		return
+	}
+
+	waitStatus, ok := state.Sys().(syscall.WaitStatus)
+	if !ok {
+		log.Error("Cannot convert ProcessState to WaitStatus!")
+		status.Code = exitCodeInternalError
+		return
+	}
+
+	status.Code = waitStatus.ExitStatus()
+	if status.Code < 0 {
+		status.Code = exitCodeInterrupt
+	}
+	if waitStatus.Signaled() {
+		status.Signal = waitStatus.Signal()
+	}
+
+	return
+}
+
+// newCommand returns new running command instance. environ is the
+// environment of the command.
+func newCommand(commandToExecute []string, environ []string, hasTTY bool) (commandToRun *command, err error) {
 	cmd := exec.Command(commandToExecute[0], commandToExecute[1:]...)
+	cmd.Env = environ
+	done := make(chan struct{})
 
 	if hasTTY {
-		cmd, err = makePTYCommand(cmd)
+		cmd, err = makePTYCommand(cmd, done)
 	} else {
 		cmd, err = makeStandardCommand(cmd)
 	}
@@ -91,16 +140,16 @@ func newCommand(commandToExecute []string, hasTTY bool) (commandToRun *command,
 		return
 	}
 
-	commandToRun = &command{cmd: cmd}
+	commandToRun = &command{cmd: cmd, done: done}
 
-	go cmd.Wait()
+	go commandToRun.wait()
 
 	return
 }
 
 // makeStandardCommand just attach streams to the command and runs it.
 func makeStandardCommand(cmd *exec.Cmd) (*exec.Cmd, error) {
-	log.WithField("cmd", cmd).Info("Run command in standard mode.")
+	log.WithField("cmd", cmd.Args).Info("Run command in standard mode.")
 
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
@@ -110,9 +159,9 @@ func makeStandardCommand(cmd *exec.Cmd) (*exec.Cmd, error) {
 }
 
 // makePTY command attaches streams to the command and run it with a
-// preconfigured pseudo TTY.
-func makePTYCommand(cmd *exec.Cmd) (*exec.Cmd, error) {
-	log.WithField("cmd", cmd).Info("Run command with PTY.")
+// preconfigured pseudo TTY. PTY is cleaned up when done channel is closed.
+func makePTYCommand(cmd *exec.Cmd, done <-chan struct{}) (*exec.Cmd, error) {
+	log.WithField("cmd", cmd.Args).Info("Run command with PTY.")
 
 	pty, err := pty.Start(cmd)
 	if err != nil {
@@ -126,17 +175,11 @@ func makePTYCommand(cmd *exec.Cmd) (*exec.Cmd, error) {
 	}
 
 	go func() {
-		defer cleanUpPTY(cmd, pty, hostFd, oldTerminalState)
-
-		for {
-			if cmd.ProcessState != nil {
-				return
-			}
-			time.Sleep(timeoutPTY)
-		}
+		<-done
+		cleanUpPTY(cmd, pty, hostFd, oldTerminalState)
 	}()
 
-	monitorTTYResize(hostFd, pty.Fd(), cmd)
+	monitorTTYResize(hostFd, pty.Fd(), done)
 
 	go io.Copy(pty, os.Stdin)
 	go io.Copy(os.Stdout, pty)
@@ -146,27 +189,27 @@ func makePTYCommand(cmd *exec.Cmd) (*exec.Cmd, error) {
 
 // cleanUpPTY closes configured PTY.
 func cleanUpPTY(cmd *exec.Cmd, pty *os.File, hostFd uintptr, state *term.State) {
-	log.WithField("cmd", cmd).Info("Cleanup PTY.")
+	log.WithField("cmd", cmd.Args).Info("Cleanup PTY.")
 
 	term.RestoreTerminal(hostFd, state)
 	pty.Close()
 }
 
 // monitorTTYResize monitors if PTY winSize was changed and changes it
-// in appropriate way.
-func monitorTTYResize(hostFd uintptr, guestFd uintptr, cmd *exec.Cmd) {
+// in appropriate way until done channel is closed.
+func monitorTTYResize(hostFd uintptr, guestFd uintptr, done <-chan struct{}) {
 	resizeTTY(hostFd, guestFd)
 
 	winchChan := make(chan os.Signal, 1)
 	signal.Notify(winchChan, syscall.SIGWINCH)
 
 	go func() {
-		tick := time.Tick(timeoutPTY)
+		defer signal.Stop(winchChan)
 
-		for cmd.ProcessState != nil {
+		for {
 			select {
-			case <-tick:
-				continue
+			case <-done:
+				return
 			case <-winchChan:
 				resizeTTY(hostFd, guestFd)
 			}
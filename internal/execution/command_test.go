@@ -0,0 +1,79 @@
+package execution
+
+import (
+	"syscall"
+	"testing"
+	"time"
+
+	assert "github.com/stretchr/testify/assert"
+
+	opts "github.com/9seconds/guidedog/internal/options"
+)
+
+func runCommand(t *testing.T, script string) *command {
+	cmd, err := newCommand([]string{"sh", "-c", script}, nil, false)
+	assert.Nil(t, err)
+
+	return cmd
+}
+
+func TestCommandExitStatus(t *testing.T) {
+	cmd := runCommand(t, "exit 3")
+	<-cmd.Done()
+
+	assert.True(t, cmd.Stopped())
+	assert.Equal(t, cmd.ExitCode(), 3)
+	assert.Equal(t, cmd.ExitStatus(), opts.ExitStatus{Code: 3})
+}
+
+func TestCommandExitStatusKilled(t *testing.T) {
+	cmd := runCommand(t, "kill -KILL $$")
+	<-cmd.Done()
+
+	assert.Equal(t, cmd.ExitStatus(), opts.ExitStatus{Code: exitCodeInterrupt, Signal: syscall.SIGKILL})
+}
+
+func TestCommandExitStatusRunning(t *testing.T) {
+	cmd := runCommand(t, "exec sleep 10")
+	defer cmd.Stop(syscall.SIGKILL, time.Second)
+
+	assert.False(t, cmd.Stopped())
+	assert.Equal(t, cmd.ExitCode(), exitCodeStillRunning)
+	assert.Equal(t, cmd.ExitStatus(), opts.ExitStatus{Code: exitCodeStillRunning})
+}
+
+func TestCommandStop(t *testing.T) {
+	cmd := runCommand(t, "exec sleep 10")
+
+	start := time.Now()
+	cmd.Stop(syscall.SIGTERM, 5*time.Second)
+
+	assert.True(t, time.Since(start) < time.Second)
+	assert.True(t, cmd.Stopped())
+	assert.Equal(t, cmd.ExitStatus().Signal, syscall.SIGTERM)
+}
+
+func TestCommandStopKillsAfterTimeout(t *testing.T) {
+	// Ignored signals stay ignored after exec.
+	cmd := runCommand(t, `trap "" TERM; exec sleep 10`)
+	time.Sleep(200 * time.Millisecond)
+
+	start := time.Now()
+	cmd.Stop(syscall.SIGTERM, 100*time.Millisecond)
+
+	assert.True(t, time.Since(start) >= 100*time.Millisecond)
+	assert.True(t, cmd.Stopped())
+	assert.Equal(t, cmd.ExitStatus().Signal, syscall.SIGKILL)
+}
+
+func TestCommandStopStopped(t *testing.T) {
+	cmd := runCommand(t, "exit 0")
+	<-cmd.Done()
+
+	cmd.Stop(syscall.SIGTERM, time.Second)
+	assert.Equal(t, cmd.ExitStatus(), opts.ExitStatus{})
+}
+
+func TestGetExitStatusWithoutState(t *testing.T) {
+	assert.Equal(t, getExitStatus(nil), opts.ExitStatus{Code: exitCodeInternalError})
+}
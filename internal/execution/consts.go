@@ -15,15 +15,13 @@ const (
 	exitCodeStillRunning  = -1
 	exitCodeInterrupt     = 130
 	exitCodeInternalError = 70
+	exitCodeCrashLoop     = 75
 )
 
 // timeout* constants family defines time.Durations for different
 // internal purposes.
 const (
-	timeoutGracefulSignal = 2 * time.Millisecond
-	timeoutSupervising    = 5 * time.Millisecond
-	timeoutPTY            = 5 * time.Millisecond
-	timeoutLockFile       = 5 * time.Millisecond
+	timeoutLockFile = 5 * time.Millisecond
 )
 
 // supervisor* constants family defines the set of actions that could be
@@ -30,12 +30,12 @@ func Execute(command []string, env *environment.Environment) int {
 		}
 	}
 
-	pathsToWatch := []string{env.Options.ConfigPath}
-	pathsToWatch = append(pathsToWatch, env.Options.PathsToTrack...)
-
-	watcherChannel := makeWatcher(pathsToWatch, env)
+	watcherChannel := makeWatcher(env.Options.PathsToTrack, env)
 	defer close(watcherChannel)
 
+	refresherChannel := makeRefresher(env)
+	defer close(refresherChannel)
+
 	exitCodeChannel := make(chan int, 1)
 	defer close(exitCodeChannel)
 
@@ -48,27 +48,66 @@ func Execute(command []string, env *environment.Environment) int {
 	go attachSignalChannel(supervisorChannel, signalChannel)
 	if env.Options.Supervisor&options.SupervisorModeRestarting > 0 {
 		go attachSupervisorChannel(supervisorChannel, watcherChannel)
+		go attachSupervisorChannel(supervisorChannel, refresherChannel)
+	}
+
+	systemd := newSystemdNotifier()
+	systemdDone := make(chan struct{})
+	defer close(systemdDone)
+	go systemd.pingWatchdog(systemdDone)
+
+	environ := env.Environ
+	var socket *notifySocket
+	if env.Options.Notify.Enabled {
+		var err error
+		if socket, err = newNotifySocket(); err != nil {
+			log.WithField("error", err).Panicf("Cannot create notify socket!")
+		}
+		defer socket.Close()
+
+		environ = func() []string {
+			return notifyEnviron(env.Environ(), socket.Path(), env.Options.Notify.WatchdogTimeout)
+		}
 	}
 
 	supervisor := newSupervisor(command,
+		environ,
 		exitCodeChannel,
 		env.Options.Signal,
 		env.Options.GracefulTimeout,
 		env.Options.PTY,
-		env.Options.Supervisor&options.SupervisorModeSimple > 0,
+		env.Options.HealthChecks,
+		env.Options.Supervisor,
+		env.Options.Notify,
+		systemd,
+		env.Options.RestartPolicy,
 		supervisorChannel,
-		env.Options.ExitCodes)
+		env.Options.Restart)
 
 	log.WithField("supervisor", supervisor).Info("Start supervisor.")
 
+	if socket != nil {
+		go socket.Serve(supervisor.notifyChannel)
+	}
+
 	supervisor.Start()
 	go func() {
 		for {
-			event, ok := <-supervisorChannel
-			if !ok {
-				return
+			select {
+			case event, ok := <-supervisorChannel:
+				if !ok {
+					return
+				}
+				supervisor.Signal(event)
+			case cmd := <-supervisor.exitChannel:
+				supervisor.Exited(cmd)
+			case cmd := <-supervisor.restartChannel:
+				supervisor.RestartDelayed(cmd)
+			case cmd := <-supervisor.unhealthyChannel:
+				supervisor.Unhealthy(cmd)
+			case message := <-supervisor.notifyChannel:
+				supervisor.Notified(message)
 			}
-			supervisor.Signal(event)
 		}
 	}()
 
@@ -0,0 +1,134 @@
+// Package execution contains all logic for execution of external commands
+// based on Environment struct.
+//
+// This file contains health checks of running command.
+package execution
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os/exec"
+	"syscall"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+
+	options "github.com/9seconds/guidedog/internal/options"
+)
+
+// healthCheckShell is a shell which runs commands of health checks.
+var healthCheckShell = []string{"/bin/sh", "-c"}
+
+// monitorHealth probes the health of the command until it exits. If
+// health check fails Retries times in a row, command is sent into
+// unhealthyChannel.
+func (s *supervisor) monitorHealth(cmd *command, check options.HealthCheck) {
+	startedAt := time.Now()
+	ticker := time.NewTicker(check.Interval)
+	defer ticker.Stop()
+
+	failures := 0
+	for {
+		select {
+		case <-cmd.Done():
+			return
+		case <-ticker.C:
+		}
+
+		err := probeHealth(check, s.environ())
+		if err == nil {
+			failures = 0
+			continue
+		}
+
+		if time.Since(startedAt) < check.StartPeriod {
+			log.WithFields(log.Fields{
+				"check": check,
+				"error": err,
+			}).Info("Health check failed within start period.")
+			continue
+		}
+
+		failures++
+		log.WithFields(log.Fields{
+			"check":    check,
+			"failures": failures,
+			"error":    err,
+		}).Warn("Health check failed.")
+
+		if failures >= check.Retries {
+			select {
+			case s.unhealthyChannel <- cmd:
+			case <-cmd.Done():
+			}
+			return
+		}
+	}
+}
+
+// probeHealth does a single probe of the health check. environ is an
+// environment of health check commands.
+func probeHealth(check options.HealthCheck, environ []string) error {
+	switch check.Type {
+	case options.HealthCheckTypeCommand:
+		return probeCommand(check.Target, environ, check.Timeout)
+	case options.HealthCheckTypeHTTP:
+		return probeHTTP(check.Target, check.Timeout)
+	case options.HealthCheckTypeTCP:
+		return probeTCP(check.Target, check.Timeout)
+	}
+
+	return fmt.Errorf("Unknown health check type %s", check.Type)
+}
+
+// probeCommand runs the command in shell. Command has to exit with zero
+// code within timeout, otherwise it is killed.
+func probeCommand(command string, environ []string, timeout time.Duration) error {
+	cmd := exec.Command(healthCheckShell[0], append(healthCheckShell[1:], command)...)
+	cmd.Env = environ
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(timeout):
+		cmd.Process.Signal(syscall.SIGKILL)
+		<-done
+		return fmt.Errorf("Command %s timed out after %s", command, timeout)
+	}
+}
+
+// probeHTTP does GET request to the URL. Response has to have 2xx or 3xx
+// status.
+func probeHTTP(url string, timeout time.Duration) error {
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("Unexpected response status %s", resp.Status)
+	}
+
+	return nil
+}
+
+// probeTCP connects to the address.
+func probeTCP(address string, timeout time.Duration) error {
+	conn, err := net.DialTimeout("tcp", address, timeout)
+	if err != nil {
+		return err
+	}
+
+	return conn.Close()
+}
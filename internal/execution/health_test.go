@@ -0,0 +1,131 @@
+package execution
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	assert "github.com/stretchr/testify/assert"
+
+	opts "github.com/9seconds/guidedog/internal/options"
+)
+
+func createHealthSupervisor() (*supervisor, *command) {
+	s := &supervisor{
+		environ:          func() []string { return os.Environ() },
+		unhealthyChannel: make(chan *command, 1),
+	}
+	cmd := &command{done: make(chan struct{})}
+
+	return s, cmd
+}
+
+func waitForUnhealthy(s *supervisor, cmd *command, timeout time.Duration) bool {
+	select {
+	case unhealthy := <-s.unhealthyChannel:
+		return unhealthy == cmd
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+func countLines(filename string) int {
+	content, _ := ioutil.ReadFile(filename)
+	return strings.Count(string(content), "\n")
+}
+
+func TestProbeHTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/slow":
+			time.Sleep(500 * time.Millisecond)
+		case "/broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	assert.Nil(t, probeHTTP(server.URL+"/health", time.Second))
+	assert.NotNil(t, probeHTTP(server.URL+"/broken", time.Second))
+	assert.NotNil(t, probeHTTP(server.URL+"/slow", 50*time.Millisecond))
+}
+
+func TestProbeTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	address := listener.Addr().String()
+
+	assert.Nil(t, probeTCP(address, time.Second))
+
+	listener.Close()
+	assert.NotNil(t, probeTCP(address, time.Second))
+}
+
+func TestProbeCommand(t *testing.T) {
+	assert.Nil(t, probeCommand("true", nil, time.Second))
+	assert.NotNil(t, probeCommand("false", nil, time.Second))
+	assert.Nil(t, probeCommand(`[ "$HEALTH" = "ok" ]`, []string{"HEALTH=ok"}, time.Second))
+
+	start := time.Now()
+	assert.NotNil(t, probeCommand("sleep 5", nil, 50*time.Millisecond))
+	assert.True(t, time.Since(start) < time.Second)
+}
+
+func TestMonitorHealthRetries(t *testing.T) {
+	counter, _ := ioutil.TempFile("", "")
+	counter.Close()
+	defer os.Remove(counter.Name())
+
+	s, cmd := createHealthSupervisor()
+	defer close(cmd.done)
+
+	// The second probe succeeds and resets failures.
+	go s.monitorHealth(cmd, opts.HealthCheck{
+		Type:     opts.HealthCheckTypeCommand,
+		Target:   "echo x >> " + counter.Name() + "; [ $(wc -l < " + counter.Name() + ") -eq 2 ]",
+		Interval: 10 * time.Millisecond,
+		Timeout:  time.Second,
+		Retries:  2,
+	})
+
+	assert.True(t, waitForUnhealthy(s, cmd, 2*time.Second))
+	assert.Equal(t, countLines(counter.Name()), 4)
+}
+
+func TestMonitorHealthStartPeriod(t *testing.T) {
+	s, cmd := createHealthSupervisor()
+	defer close(cmd.done)
+
+	start := time.Now()
+	go s.monitorHealth(cmd, opts.HealthCheck{
+		Type:        opts.HealthCheckTypeCommand,
+		Target:      "false",
+		Interval:    10 * time.Millisecond,
+		Timeout:     time.Second,
+		Retries:     2,
+		StartPeriod: 200 * time.Millisecond,
+	})
+
+	assert.True(t, waitForUnhealthy(s, cmd, 2*time.Second))
+	assert.True(t, time.Since(start) >= 200*time.Millisecond)
+}
+
+func TestMonitorHealthStopsWithCommand(t *testing.T) {
+	s, cmd := createHealthSupervisor()
+
+	go s.monitorHealth(cmd, opts.HealthCheck{
+		Type:     opts.HealthCheckTypeCommand,
+		Target:   "false",
+		Interval: 50 * time.Millisecond,
+		Timeout:  time.Second,
+		Retries:  1,
+	})
+	close(cmd.done)
+
+	assert.False(t, waitForUnhealthy(s, cmd, 200*time.Millisecond))
+}
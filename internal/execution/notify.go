@@ -0,0 +1,217 @@
+// Package execution contains all logic for execution of external commands
+// based on Environment struct.
+//
+// This file contains sd_notify protocol support: notifications of systemd
+// and notify socket of the command.
+package execution
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// sd_notify protocol environment variables.
+const (
+	notifySocketEnvVariable = "NOTIFY_SOCKET"
+	watchdogUSecEnvVariable = "WATCHDOG_USEC"
+	watchdogPIDEnvVariable  = "WATCHDOG_PID"
+)
+
+// notifySocketName is a name of notify socket of the command in its
+// temporary directory.
+const notifySocketName = "notify.sock"
+
+// notifyMessageSize is a maximal size of notify message.
+const notifyMessageSize = 4096
+
+// systemdNotifier sends notifications to systemd. nil notifier (if
+// guidedog is not started by systemd with NOTIFY_SOCKET) does nothing.
+type systemdNotifier struct {
+	address  *net.UnixAddr
+	watchdog time.Duration
+}
+
+// Notify sends state lines like "READY=1" to systemd.
+func (n *systemdNotifier) Notify(state ...string) {
+	if n == nil {
+		return
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, n.address)
+	if err != nil {
+		log.WithField("error", err).Warn("Cannot connect to systemd notify socket.")
+		return
+	}
+	defer conn.Close()
+
+	if _, err = conn.Write([]byte(strings.Join(state, "\n"))); err != nil {
+		log.WithField("error", err).Warn("Cannot notify systemd.")
+	}
+}
+
+// pingWatchdog sends WATCHDOG=1 to systemd every half of watchdog interval
+// until done is closed. Does nothing if systemd watchdog is disabled.
+func (n *systemdNotifier) pingWatchdog(done chan struct{}) {
+	if n == nil || n.watchdog <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(n.watchdog / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			n.Notify("WATCHDOG=1")
+		}
+	}
+}
+
+// newSystemdNotifier returns systemd notifier based on environment of
+// guidedog or nil if it is not started with NOTIFY_SOCKET.
+func newSystemdNotifier() *systemdNotifier {
+	socket := os.Getenv(notifySocketEnvVariable)
+	if socket == "" {
+		return nil
+	}
+
+	notifier := &systemdNotifier{
+		address: &net.UnixAddr{Name: socket, Net: "unixgram"},
+	}
+
+	pid := os.Getenv(watchdogPIDEnvVariable)
+	if usec, err := strconv.ParseInt(os.Getenv(watchdogUSecEnvVariable), 10, 64); err == nil && usec > 0 {
+		if pid == "" || pid == strconv.Itoa(os.Getpid()) {
+			notifier.watchdog = time.Duration(usec) * time.Microsecond
+		}
+	}
+
+	return notifier
+}
+
+// notifySocket is a socket the command sends its notifications to. It
+// lives in its own temporary directory.
+type notifySocket struct {
+	conn      *net.UnixConn
+	directory string
+}
+
+// Path returns the path of the socket.
+func (ns *notifySocket) Path() string {
+	return filepath.Join(ns.directory, notifySocketName)
+}
+
+// Close closes the socket and removes its directory.
+func (ns *notifySocket) Close() {
+	ns.conn.Close()
+	os.RemoveAll(ns.directory)
+}
+
+// Serve reads notifications and sends parsed ones into channel until the
+// socket is closed.
+func (ns *notifySocket) Serve(channel chan map[string]string) {
+	buffer := make([]byte, notifyMessageSize)
+
+	for {
+		size, err := ns.conn.Read(buffer)
+		if err != nil {
+			log.WithField("error", err).Debug("Notify socket is closed.")
+			return
+		}
+
+		message := parseNotifyMessage(buffer[:size])
+		log.WithField("message", message).Debug("Notification from command.")
+		channel <- message
+	}
+}
+
+// newNotifySocket creates notify socket of the command.
+func newNotifySocket() (*notifySocket, error) {
+	directory, err := ioutil.TempDir("", "guidedog")
+	if err != nil {
+		return nil, err
+	}
+
+	socket := &notifySocket{directory: directory}
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket.Path(), Net: "unixgram"})
+	if err != nil {
+		os.RemoveAll(directory)
+		return nil, err
+	}
+	socket.conn = conn
+
+	return socket, nil
+}
+
+// parseNotifyMessage parses newline-separated KEY=VALUE lines of sd_notify
+// message.
+func parseNotifyMessage(data []byte) map[string]string {
+	message := make(map[string]string)
+
+	for _, line := range strings.Split(string(data), "\n") {
+		split := strings.SplitN(line, "=", 2)
+		if len(split) == 2 && split[0] != "" {
+			message[split[0]] = split[1]
+		}
+	}
+
+	return message
+}
+
+// notifyEnviron replaces sd_notify variables of environment by the ones
+// of notify socket of the command. WATCHDOG_USEC is set only if watchdog
+// is enabled.
+func notifyEnviron(environ []string, socketPath string, watchdogTimeout time.Duration) []string {
+	converted := make([]string, 0, len(environ)+2)
+
+	for _, item := range environ {
+		name := strings.SplitN(item, "=", 2)[0]
+		switch name {
+		case notifySocketEnvVariable, watchdogUSecEnvVariable, watchdogPIDEnvVariable:
+			continue
+		}
+		converted = append(converted, item)
+	}
+
+	converted = append(converted, notifySocketEnvVariable+"="+socketPath)
+	if watchdogTimeout > 0 {
+		converted = append(converted,
+			fmt.Sprintf("%s=%d", watchdogUSecEnvVariable, watchdogTimeout/time.Microsecond))
+	}
+
+	return converted
+}
+
+// monitorWatchdog restarts the command if it does not send WATCHDOG=1
+// within timeout since the start or the previous ping. Pings are read from
+// the given channel.
+func (s *supervisor) monitorWatchdog(cmd *command, pings chan struct{}, timeout time.Duration) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-cmd.Done():
+			return
+		case <-pings:
+			timer.Reset(timeout)
+		case <-timer.C:
+			log.WithField("timeout", timeout).Warn("Command missed watchdog ping, restart it.")
+			select {
+			case s.unhealthyChannel <- cmd:
+			case <-cmd.Done():
+			}
+			return
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package execution
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	assert "github.com/stretchr/testify/assert"
+
+	environment "github.com/9seconds/guidedog/internal/environment"
+	opts "github.com/9seconds/guidedog/internal/options"
+)
+
+func createEnvironment(t *testing.T) *environment.Environment {
+	options, err := opts.NewOptions("term", // signal
+		[]string{},  // envs
+		time.Second, // gracefulTimeout
+		"",          // configFormat
+		[]string{},  // configPaths
+		[]string{},  // pathsToTracks
+		"",          // lockFile
+		false,       // pty
+		false,       // supervise
+		false,       // restartOnConfigChanges
+		[]string{})  // exitCodes
+	assert.Nil(t, err)
+
+	env, err := environment.NewEnvironment(options)
+	assert.Nil(t, err)
+
+	return env
+}
+
+// listenSystemd creates a local unix datagram socket which pretends to be
+// systemd notify socket.
+func listenSystemd(t *testing.T) (*net.UnixConn, string) {
+	tempDir, _ := ioutil.TempDir("", "")
+	address := filepath.Join(tempDir, "systemd.sock")
+
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: address, Net: "unixgram"})
+	assert.Nil(t, err)
+
+	return conn, address
+}
+
+func readDatagram(t *testing.T, conn *net.UnixConn) string {
+	buffer := make([]byte, notifyMessageSize)
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	size, err := conn.Read(buffer)
+	assert.Nil(t, err)
+
+	return string(buffer[:size])
+}
+
+func sendDatagram(t *testing.T, address string, message string) {
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: address, Net: "unixgram"})
+	assert.Nil(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte(message))
+	assert.Nil(t, err)
+}
+
+func TestParseNotifyMessage(t *testing.T) {
+	message := parseNotifyMessage([]byte("READY=1\nSTATUS=Listening on 8080=ok\n\nbroken\n=empty"))
+
+	assert.Equal(t, message, map[string]string{
+		"READY":  "1",
+		"STATUS": "Listening on 8080=ok",
+	})
+}
+
+func TestNotifyEnviron(t *testing.T) {
+	environ := []string{"A=1", "NOTIFY_SOCKET=/run/systemd/notify", "WATCHDOG_USEC=100", "WATCHDOG_PID=1"}
+
+	assert.Equal(t, notifyEnviron(environ, "/tmp/notify.sock", 0),
+		[]string{"A=1", "NOTIFY_SOCKET=/tmp/notify.sock"})
+	assert.Equal(t, notifyEnviron(environ, "/tmp/notify.sock", 3*time.Second),
+		[]string{"A=1", "NOTIFY_SOCKET=/tmp/notify.sock", "WATCHDOG_USEC=3000000"})
+}
+
+func TestNewSystemdNotifier(t *testing.T) {
+	defer os.Unsetenv(notifySocketEnvVariable)
+	defer os.Unsetenv(watchdogUSecEnvVariable)
+	defer os.Unsetenv(watchdogPIDEnvVariable)
+
+	os.Unsetenv(notifySocketEnvVariable)
+	assert.Nil(t, newSystemdNotifier())
+
+	conn, address := listenSystemd(t)
+	defer os.RemoveAll(filepath.Dir(address))
+	defer conn.Close()
+
+	os.Setenv(notifySocketEnvVariable, address)
+	os.Setenv(watchdogUSecEnvVariable, "2000000")
+	os.Setenv(watchdogPIDEnvVariable, strconv.Itoa(os.Getpid()))
+	notifier := newSystemdNotifier()
+	assert.Equal(t, notifier.watchdog, 2*time.Second)
+
+	notifier.Notify("READY=1", "STATUS=ok")
+	assert.Equal(t, readDatagram(t, conn), "READY=1\nSTATUS=ok")
+
+	os.Setenv(watchdogPIDEnvVariable, "1")
+	assert.Equal(t, newSystemdNotifier().watchdog, time.Duration(0))
+
+	var nilNotifier *systemdNotifier
+	nilNotifier.Notify("READY=1")
+}
+
+func TestNotifySocketRoundTrip(t *testing.T) {
+	systemdConn, systemdAddress := listenSystemd(t)
+	defer os.RemoveAll(filepath.Dir(systemdAddress))
+	defer systemdConn.Close()
+
+	socket, err := newNotifySocket()
+	assert.Nil(t, err)
+	defer socket.Close()
+
+	s := &supervisor{
+		cmd:              &command{done: make(chan struct{})},
+		notifyChannel:    make(chan map[string]string, 1),
+		systemd:          &systemdNotifier{address: &net.UnixAddr{Name: systemdAddress, Net: "unixgram"}},
+		unhealthyChannel: make(chan *command, 1),
+		watchdogChannel:  make(chan struct{}, 1),
+	}
+	go socket.Serve(s.notifyChannel)
+
+	sendDatagram(t, socket.Path(), "READY=1\nSTATUS=Started")
+	s.Notified(<-s.notifyChannel)
+	assert.Equal(t, readDatagram(t, systemdConn), "READY=1\nSTATUS=Started")
+
+	sendDatagram(t, socket.Path(), "WATCHDOG=1")
+	s.Notified(<-s.notifyChannel)
+	select {
+	case <-s.watchdogChannel:
+	default:
+		t.Error("Watchdog ping is not passed.")
+	}
+
+	sendDatagram(t, socket.Path(), "STATUS=Serving")
+	s.Notified(<-s.notifyChannel)
+	assert.Equal(t, readDatagram(t, systemdConn), "STATUS=Serving")
+}
+
+func TestMonitorWatchdogMissedPing(t *testing.T) {
+	s := &supervisor{unhealthyChannel: make(chan *command, 1)}
+	cmd := &command{done: make(chan struct{})}
+	defer close(cmd.done)
+
+	pings := make(chan struct{}, 1)
+	start := time.Now()
+	go s.monitorWatchdog(cmd, pings, 100*time.Millisecond)
+
+	for idx := 0; idx < 4; idx++ {
+		time.Sleep(50 * time.Millisecond)
+		pings <- struct{}{}
+	}
+
+	select {
+	case unhealthy := <-s.unhealthyChannel:
+		assert.Equal(t, unhealthy, cmd)
+		assert.True(t, time.Since(start) >= 250*time.Millisecond)
+	case <-time.After(2 * time.Second):
+		t.Error("Missed watchdog ping does not restart command.")
+	}
+}
+
+func TestWatchdogRestartsCommand(t *testing.T) {
+	counter, _ := ioutil.TempFile("", "")
+	counter.Close()
+	defer os.Remove(counter.Name())
+
+	env := createEnvironment(t)
+	assert.Nil(t, opts.WithNotify(true, 200*time.Millisecond)(env.Options))
+
+	// First run never pings watchdog and is restarted, second one exits.
+	script := "echo x >> " + counter.Name() + "; [ $(wc -l < " + counter.Name() + ") -ge 2 ] && exit 3; exec sleep 10"
+	exitCode := Execute([]string{"/bin/sh", "-c", script}, env)
+
+	assert.Equal(t, exitCode, 3)
+	assert.Equal(t, countLines(counter.Name()), 2)
+}
@@ -0,0 +1,58 @@
+// Package execution contains all logic for execution of external commands
+// based on Environment struct.
+//
+// This file contains periodic refresh of environment from commands of
+// config sources.
+package execution
+
+import (
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+
+	environment "github.com/9seconds/guidedog/internal/environment"
+)
+
+// makeRefresher re-runs commands of config sources periodically and sends
+// notifications into channel if environment is changed.
+func makeRefresher(env *environment.Environment) (channel chan bool) {
+	channel = make(chan bool, 1)
+
+	if env.Options.ExecInterval == 0 {
+		return
+	}
+
+	hasCommands := false
+	for _, source := range env.Options.ConfigSources {
+		hasCommands = hasCommands || source.Exec
+	}
+	if !hasCommands {
+		return
+	}
+
+	go refresherLoop(env, channel, time.Tick(env.Options.ExecInterval))
+
+	return
+}
+
+// refresherLoop defines main refresher loop.
+func refresherLoop(env *environment.Environment, channel chan bool, ticker <-chan time.Time) {
+	for range ticker {
+		variables := env.Variables()
+
+		if _, err := reloadEnvironment(env); err != nil {
+			continue
+		}
+
+		changedVariables := changedKeys(variables, env.Variables())
+		if len(changedVariables) == 0 {
+			log.Debug("Environment is not changed after refresh.")
+			continue
+		}
+
+		log.WithField("changedVariables", changedVariables).Info("Environment is changed after refresh.")
+		if len(channel) == 0 {
+			channel <- true
+		}
+	}
+}
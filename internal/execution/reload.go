@@ -0,0 +1,60 @@
+// Package execution contains all logic for execution of external commands
+// based on Environment struct.
+//
+// This file contains reloading of environment on changes.
+package execution
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	log "github.com/Sirupsen/logrus"
+
+	environment "github.com/9seconds/guidedog/internal/environment"
+)
+
+// reloadErrorEnvVariable is a name of environment variable which contains
+// the error of failed reload for the failure hook.
+const reloadErrorEnvVariable = "GUIDEDOG_RELOAD_ERROR"
+
+// reloadEnvironment updates environment and tells if command may be
+// restarted. Environment which does not match schema never restarts the
+// command, other errors do not restart it only in strict mode. Failed
+// update always keeps the current environment and its error is returned.
+// Failure is printed to stderr because logging is usually silenced.
+func reloadEnvironment(env *environment.Environment) (bool, error) {
+	err := env.Update()
+	if err == nil {
+		return true, nil
+	}
+
+	if env.Options.Reload.FailureHook != "" {
+		go runReloadFailureHook(env.Options.Reload.FailureHook, err)
+	}
+
+	if _, ok := err.(*environment.ValidationError); !ok && !env.Options.Reload.Strict {
+		fmt.Fprintf(os.Stderr, "Cannot reload environment, keep the current one: %v\n", err)
+		return true, err
+	}
+
+	fmt.Fprintf(os.Stderr, "Cannot reload environment, keep the current one and skip restart: %v\n", err)
+
+	return false, err
+}
+
+// runReloadFailureHook runs the hook command in shell. The error of
+// reload is passed in GUIDEDOG_RELOAD_ERROR environment variable.
+func runReloadFailureHook(hook string, reloadErr error) {
+	cmd := exec.Command("/bin/sh", "-c", hook)
+	cmd.Env = append(os.Environ(), reloadErrorEnvVariable+"="+reloadErr.Error())
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		log.WithFields(log.Fields{
+			"hook":  hook,
+			"error": err,
+		}).Error("Reload failure hook failed.")
+	}
+}
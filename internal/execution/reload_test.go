@@ -0,0 +1,133 @@
+package execution
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	assert "github.com/stretchr/testify/assert"
+
+	environment "github.com/9seconds/guidedog/internal/environment"
+	opts "github.com/9seconds/guidedog/internal/options"
+)
+
+func createReloadEnvironment(t *testing.T, filename string, strict bool, hook string, schema opts.Schema) *environment.Environment {
+	options, err := opts.NewOptions("term", // signal
+		[]string{},         // envs
+		time.Second,        // gracefulTimeout
+		"json",             // configFormat
+		[]string{filename}, // configPaths
+		[]string{},         // pathsToTracks
+		"",                 // lockFile
+		false,              // pty
+		false,              // supervise
+		false,              // restartOnConfigChanges
+		[]string{},         // exitCodes
+		opts.WithReload(strict, hook))
+	assert.Nil(t, err)
+	options.Schema = schema
+
+	env, err := environment.NewEnvironment(options)
+	assert.Nil(t, err)
+
+	return env
+}
+
+func createTempConfig(content string) string {
+	fd, _ := ioutil.TempFile("", "")
+	defer fd.Close()
+
+	fd.WriteString(content)
+
+	return fd.Name()
+}
+
+func TestReloadEnvironment(t *testing.T) {
+	filename := createTempConfig(`{"FOO": "bar"}`)
+	defer os.Remove(filename)
+
+	env := createReloadEnvironment(t, filename, true, "", opts.Schema{})
+	ioutil.WriteFile(filename, []byte(`{"FOO": "baz"}`), os.FileMode(0666))
+
+	restart, err := reloadEnvironment(env)
+	assert.True(t, restart)
+	assert.Nil(t, err)
+	assert.Equal(t, env.Variables()["FOO"], "baz")
+}
+
+func TestReloadEnvironmentStrict(t *testing.T) {
+	filename := createTempConfig(`{"FOO": "bar"}`)
+	defer os.Remove(filename)
+
+	env := createReloadEnvironment(t, filename, true, "", opts.Schema{})
+	ioutil.WriteFile(filename, []byte(`{"FOO": `), os.FileMode(0666))
+
+	restart, err := reloadEnvironment(env)
+	assert.False(t, restart)
+	assert.NotNil(t, err)
+	assert.Equal(t, env.Variables()["FOO"], "bar")
+}
+
+func TestReloadEnvironmentNotStrict(t *testing.T) {
+	filename := createTempConfig(`{"FOO": "bar"}`)
+	defer os.Remove(filename)
+
+	env := createReloadEnvironment(t, filename, false, "", opts.Schema{})
+	ioutil.WriteFile(filename, []byte(`{"FOO": `), os.FileMode(0666))
+
+	restart, err := reloadEnvironment(env)
+	assert.True(t, restart)
+	assert.NotNil(t, err)
+	assert.Equal(t, env.Variables()["FOO"], "bar")
+}
+
+func TestReloadEnvironmentValidationError(t *testing.T) {
+	filename := createTempConfig(`{"FOO": "bar"}`)
+	defer os.Remove(filename)
+
+	schema := opts.Schema{Variables: []opts.SchemaVariable{
+		{Name: "FOO", Required: true},
+	}}
+	env := createReloadEnvironment(t, filename, false, "", schema)
+	ioutil.WriteFile(filename, []byte(`{"BAR": "baz"}`), os.FileMode(0666))
+
+	restart, err := reloadEnvironment(env)
+	assert.False(t, restart)
+	assert.IsType(t, err, &environment.ValidationError{})
+	assert.Equal(t, env.Variables()["FOO"], "bar")
+}
+
+func TestReloadEnvironmentRunsFailureHook(t *testing.T) {
+	tempDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(tempDir)
+
+	filename := filepath.Join(tempDir, "config.json")
+	hookOutput := filepath.Join(tempDir, "hook")
+	ioutil.WriteFile(filename, []byte(`{"FOO": "bar"}`), os.FileMode(0666))
+
+	env := createReloadEnvironment(t, filename, true, `echo "$GUIDEDOG_RELOAD_ERROR" > `+hookOutput, opts.Schema{})
+	ioutil.WriteFile(filename, []byte(`{"FOO": `), os.FileMode(0666))
+
+	_, err := reloadEnvironment(env)
+	assert.NotNil(t, err)
+
+	for i := 0; i < 100 && countLines(hookOutput) == 0; i++ {
+		time.Sleep(20 * time.Millisecond)
+	}
+	content, _ := ioutil.ReadFile(hookOutput)
+	assert.Equal(t, string(content), err.Error()+"\n")
+}
+
+func TestRunReloadFailureHook(t *testing.T) {
+	hookOutput, _ := ioutil.TempFile("", "")
+	hookOutput.Close()
+	defer os.Remove(hookOutput.Name())
+
+	runReloadFailureHook(`echo "$GUIDEDOG_RELOAD_ERROR" > `+hookOutput.Name(), fmt.Errorf("Broken config"))
+
+	content, _ := ioutil.ReadFile(hookOutput.Name())
+	assert.Equal(t, string(content), "Broken config\n")
+}
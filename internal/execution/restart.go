@@ -0,0 +1,56 @@
+// Package execution contains all logic for execution of external commands
+// based on Environment struct.
+//
+// This file contains calculations of restarts after failed starts.
+package execution
+
+import (
+	"time"
+
+	options "github.com/9seconds/guidedog/internal/options"
+)
+
+// restartState tracks failed starts of the supervised command.
+type restartState struct {
+	consecutiveFailures int
+	failedStarts        []time.Time
+}
+
+// nextRestart decides how to restart the command which was started at
+// startedAt and exited at now. Command which has run at least StartTime
+// resets the state and is restarted immediately. Otherwise it is a failed
+// start: delay is a backoff randomized by jitter (random is a number in
+// [0, 1)). Returns new state, delay before restart and exit code which is
+// exitCodeCrashLoop if there are too many failed starts within the window
+// or exitCodeStillRunning if command has to be restarted.
+func nextRestart(restart options.Restart,
+	state restartState,
+	startedAt time.Time,
+	now time.Time,
+	random float64) (restartState, time.Duration, int) {
+	if now.Sub(startedAt) >= restart.StartTime {
+		return restartState{}, 0, exitCodeStillRunning
+	}
+
+	failedStarts := make([]time.Time, 0, len(state.failedStarts)+1)
+	for _, failedAt := range append(state.failedStarts, now) {
+		if restart.FailedStartsWindow == 0 || now.Sub(failedAt) <= restart.FailedStartsWindow {
+			failedStarts = append(failedStarts, failedAt)
+		}
+	}
+	state = restartState{
+		consecutiveFailures: state.consecutiveFailures + 1,
+		failedStarts:        failedStarts,
+	}
+
+	if restart.MaxFailedStarts > 0 && len(state.failedStarts) >= restart.MaxFailedStarts {
+		return state, 0, exitCodeCrashLoop
+	}
+
+	delay := restart.Backoff(state.consecutiveFailures)
+	if restart.Jitter > 0 {
+		delay += time.Duration(float64(delay) * restart.Jitter * (2*random - 1))
+	}
+
+	return state, delay, exitCodeStillRunning
+}
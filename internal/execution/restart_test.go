@@ -0,0 +1,122 @@
+package execution
+
+import (
+	"testing"
+	"time"
+
+	assert "github.com/stretchr/testify/assert"
+
+	opts "github.com/9seconds/guidedog/internal/options"
+)
+
+func TestNextRestartBackoff(t *testing.T) {
+	restart := opts.Restart{
+		Delay:     time.Second,
+		MaxDelay:  5 * time.Second,
+		StartTime: time.Second,
+	}
+	now := time.Now()
+
+	state := restartState{}
+	for _, expected := range []time.Duration{
+		time.Second,
+		2 * time.Second,
+		4 * time.Second,
+		5 * time.Second,
+		5 * time.Second,
+	} {
+		var delay time.Duration
+		var exitCode int
+		state, delay, exitCode = nextRestart(restart, state, now, now, 0.5)
+		assert.Equal(t, delay, expected)
+		assert.Equal(t, exitCode, exitCodeStillRunning)
+	}
+	assert.Equal(t, state.consecutiveFailures, 5)
+}
+
+func TestNextRestartJitter(t *testing.T) {
+	restart := opts.Restart{
+		Delay:     time.Second,
+		MaxDelay:  time.Minute,
+		Jitter:    0.1,
+		StartTime: time.Second,
+	}
+	now := time.Now()
+
+	for _, data := range []struct {
+		random float64
+		delay  time.Duration
+	}{
+		{0, 900 * time.Millisecond},
+		{0.25, 950 * time.Millisecond},
+		{0.5, time.Second},
+		{0.75, 1050 * time.Millisecond},
+	} {
+		_, delay, _ := nextRestart(restart, restartState{}, now, now, data.random)
+		assert.Equal(t, delay, data.delay)
+	}
+
+	for random := 0.0; random < 1; random += 0.01 {
+		_, delay, _ := nextRestart(restart, restartState{}, now, now, random)
+		assert.True(t, delay >= 900*time.Millisecond)
+		assert.True(t, delay < 1100*time.Millisecond)
+	}
+}
+
+func TestNextRestartResetsAfterLongRun(t *testing.T) {
+	restart := opts.Restart{
+		Delay:           time.Second,
+		MaxDelay:        time.Minute,
+		StartTime:       time.Second,
+		MaxFailedStarts: 3,
+	}
+	now := time.Now()
+
+	state := restartState{consecutiveFailures: 2, failedStarts: []time.Time{now, now}}
+	state, delay, exitCode := nextRestart(restart, state, now.Add(-time.Second), now, 0.5)
+	assert.Equal(t, state, restartState{})
+	assert.Equal(t, delay, time.Duration(0))
+	assert.Equal(t, exitCode, exitCodeStillRunning)
+}
+
+func TestNextRestartCrashLoop(t *testing.T) {
+	restart := opts.Restart{
+		Delay:              time.Second,
+		MaxDelay:           time.Minute,
+		StartTime:          time.Second,
+		MaxFailedStarts:    3,
+		FailedStartsWindow: time.Minute,
+	}
+	now := time.Now()
+
+	state := restartState{}
+	exitCode := 0
+	for idx := 0; idx < 2; idx++ {
+		state, _, exitCode = nextRestart(restart, state, now, now, 0.5)
+		assert.Equal(t, exitCode, exitCodeStillRunning)
+	}
+	state, _, exitCode = nextRestart(restart, state, now, now, 0.5)
+	assert.Equal(t, exitCode, exitCodeCrashLoop)
+	assert.Len(t, state.failedStarts, 3)
+}
+
+func TestNextRestartFailedStartsWindow(t *testing.T) {
+	restart := opts.Restart{
+		Delay:              time.Second,
+		MaxDelay:           time.Minute,
+		StartTime:          time.Second,
+		MaxFailedStarts:    3,
+		FailedStartsWindow: time.Minute,
+	}
+	now := time.Now()
+
+	state := restartState{}
+	for idx := 0; idx < 10; idx++ {
+		failedAt := now.Add(time.Duration(idx) * 2 * time.Minute)
+		var exitCode int
+		state, _, exitCode = nextRestart(restart, state, failedAt, failedAt, 0.5)
+		assert.Equal(t, exitCode, exitCodeStillRunning)
+		assert.Len(t, state.failedStarts, 1)
+	}
+	assert.Equal(t, state.consecutiveFailures, 10)
+}
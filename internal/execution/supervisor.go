@@ -6,26 +6,44 @@ package execution
 
 import (
 	"fmt"
+	"math/rand"
 	"os"
-	"sync"
 	"time"
 
 	log "github.com/Sirupsen/logrus"
+
+	options "github.com/9seconds/guidedog/internal/options"
 )
 
 // supervisor defines structure which has all required data for supervising
-// of running process.
+// of running process. All methods except of the initial Start have to be
+// called from the single goroutine which reads supervisorChannel,
+// exitChannel, restartChannel, unhealthyChannel and notifyChannel.
 type supervisor struct {
-	allowedExitCodes  map[int]bool
 	cmd               *command
 	command           []string
+	environ           func() []string
+	exitChannel       chan *command
 	exitCodeChannel   chan int
 	gracefulSignal    os.Signal
 	gracefulTimeout   time.Duration
 	hasTTY            bool
-	keepAlivers       *sync.WaitGroup
-	restartOnFailures bool
+	healthChecks      []options.HealthCheck
+	mode              options.SupervisorMode
+	notify            options.Notify
+	notifyChannel     chan map[string]string
+	policy            options.RestartPolicy
+	random            *rand.Rand
+	restart           options.Restart
+	restartChannel    chan *command
+	restartState      restartState
+	restarts          int
+	startedAt         time.Time
+	stopping          bool
 	supervisorChannel chan supervisorAction
+	systemd           *systemdNotifier
+	unhealthyChannel  chan *command
+	watchdogChannel   chan struct{}
 }
 
 func (s *supervisor) String() string {
@@ -36,18 +54,28 @@ func (s *supervisor) String() string {
 func (s *supervisor) Start() {
 	s.stop()
 
-	if cmd, err := newCommand(s.command, s.hasTTY); err != nil {
+	if cmd, err := newCommand(s.command, s.environ(), s.hasTTY); err != nil {
 		log.WithField("error", err).Panicf("Cannot start command!")
 	} else {
 		s.cmd = cmd
+		s.startedAt = time.Now()
+		s.stopping = false
 	}
 
 	log.WithField("cmd", s.cmd).Info("Start process.")
 
-	if s.restartOnFailures {
-		go s.keepAlive()
-	} else {
-		go s.getExitCode()
+	go s.waitForExit(s.cmd)
+	for _, check := range s.healthChecks {
+		go s.monitorHealth(s.cmd, check)
+	}
+
+	s.watchdogChannel = nil
+	if s.notify.WatchdogTimeout > 0 {
+		s.watchdogChannel = make(chan struct{}, 1)
+		go s.monitorWatchdog(s.cmd, s.watchdogChannel, s.notify.WatchdogTimeout)
+	}
+	if !s.notify.Enabled {
+		s.systemd.Notify("READY=1")
 	}
 }
 
@@ -57,15 +85,135 @@ func (s *supervisor) Signal(event supervisorAction) {
 	switch event {
 	case supervisorRestart:
 		log.WithField("event", event).Info("Incoming restart event.")
+		s.systemd.Notify("RELOADING=1")
 		s.stop()
 		s.Start()
 	case supervisorStop:
 		log.WithField("event", event).Info("Incoming stop event.")
+		if s.stopping {
+			log.Debug("Supervisor is stopping already.")
+			return
+		}
+		s.stopping = true
+		s.systemd.Notify("STOPPING=1")
 		s.stop()
 		s.exitCodeChannel <- s.cmd.ExitCode()
 	}
 }
 
+// Exited defines a callback for the exit of the command. Exits of the
+// commands which are stopped by supervisor are ignored. Otherwise command
+// is restarted if restart policy says so, or its exit code is published.
+func (s *supervisor) Exited(cmd *command) {
+	if cmd != s.cmd || s.stopping {
+		log.WithField("cmd", cmd).Debug("Exit of stopped command, skip.")
+		return
+	}
+
+	exitStatus := cmd.ExitStatus()
+	if !s.mode.ShouldRestart(s.policy, exitStatus, s.restarts) {
+		log.WithFields(log.Fields{
+			"exitStatus": exitStatus,
+			"mode":       s.mode,
+			"restarts":   s.restarts,
+		}).Debug("Restart policy means we have to stop the execution.")
+		s.Signal(supervisorStop)
+		return
+	}
+
+	log.Debug("Process is stopped, restarting.")
+	s.restarts++
+	s.scheduleRestart(cmd)
+}
+
+// RestartDelayed defines a callback for the delayed restart of exited
+// command. Restart is skipped if command was restarted or stopped
+// meanwhile.
+func (s *supervisor) RestartDelayed(cmd *command) {
+	if cmd != s.cmd || s.stopping {
+		log.WithField("cmd", cmd).Debug("Command is restarted or stopped already, skip.")
+		return
+	}
+
+	s.Signal(supervisorRestart)
+}
+
+// Unhealthy defines a callback for the failed health check of the command.
+// Command is restarted unless it was restarted or stopped meanwhile.
+func (s *supervisor) Unhealthy(cmd *command) {
+	if cmd != s.cmd || s.stopping {
+		log.WithField("cmd", cmd).Debug("Command is restarted or stopped already, skip.")
+		return
+	}
+
+	log.WithField("cmd", cmd).Warn("Command is unhealthy, restarting.")
+	s.Signal(supervisorRestart)
+}
+
+// Notified defines a callback for the notification sent by the command
+// into its notify socket. Readiness and status are passed to systemd,
+// watchdog pings reset watchdog timer. WATCHDOG=trigger restarts command
+// immediately.
+func (s *supervisor) Notified(message map[string]string) {
+	if s.stopping {
+		log.WithField("message", message).Debug("Supervisor is stopping, skip notification.")
+		return
+	}
+
+	state := make([]string, 0, 2)
+	if message["READY"] == "1" {
+		state = append(state, "READY=1")
+	}
+	if status, ok := message["STATUS"]; ok {
+		state = append(state, "STATUS="+status)
+	}
+	if len(state) > 0 {
+		s.systemd.Notify(state...)
+	}
+
+	switch message["WATCHDOG"] {
+	case "1":
+		select {
+		case s.watchdogChannel <- struct{}{}:
+		default:
+		}
+	case "trigger":
+		log.WithField("cmd", s.cmd).Warn("Command triggered watchdog, restart it.")
+		s.Unhealthy(s.cmd)
+	}
+}
+
+// scheduleRestart restarts exited command according to the restart
+// policy. Command which has run long enough is restarted immediately.
+// Otherwise it is a failed start: command is restarted with backoff or
+// supervising is stopped if there are too many failed starts.
+func (s *supervisor) scheduleRestart(cmd *command) {
+	state, delay, exitCode := nextRestart(s.restart, s.restartState, s.startedAt, time.Now(), s.random.Float64())
+	s.restartState = state
+
+	switch {
+	case exitCode == exitCodeCrashLoop:
+		log.WithFields(log.Fields{
+			"failedStarts": len(state.failedStarts),
+			"window":       s.restart.FailedStartsWindow,
+		}).Error("Crash loop is detected, stop supervising.")
+		s.stopping = true
+		s.systemd.Notify("STOPPING=1")
+		s.exitCodeChannel <- exitCode
+	case state.consecutiveFailures == 0:
+		s.Signal(supervisorRestart)
+	default:
+		log.WithFields(log.Fields{
+			"consecutiveFailures": state.consecutiveFailures,
+			"delay":               delay,
+		}).Warn("Command failed to start, restart with delay.")
+
+		time.AfterFunc(delay, func() {
+			s.restartChannel <- cmd
+		})
+	}
+}
+
 // stopped just a thin wrapper which tells if command is stopped or not.
 func (s *supervisor) stopped() bool {
 	if s.cmd == nil {
@@ -78,10 +226,6 @@ func (s *supervisor) stopped() bool {
 func (s *supervisor) stop() {
 	log.Info("Stop external process.")
 
-	log.Debug("Disable keepalivers.")
-	s.keepAlivers.Wait()
-	log.Debug("Keepalivers disabled.")
-
 	if !s.stopped() {
 		log.Debug("Start stopping process.")
 		s.cmd.Stop(s.gracefulSignal, s.gracefulTimeout)
@@ -90,64 +234,50 @@ func (s *supervisor) stop() {
 	}
 }
 
-// keepAlive is just a function to be executed in goroutine. It tracks
-// command execution and restarts if necessary.
-func (s *supervisor) keepAlive() {
-	s.keepAlivers.Add(1)
-	defer s.keepAlivers.Done()
-
-	log.Debug("Start keepaliver.")
-	for {
-		if s.stopped() {
-			exitCode := s.cmd.ExitCode()
-			if _, ok := s.allowedExitCodes[exitCode]; ok {
-				log.WithFields(log.Fields{
-					"exitCode":     exitCode,
-					"allowedCodes": s.allowedExitCodes,
-				}).Debug("Exit code means we have to stop the execution.")
-				s.supervisorChannel <- supervisorStop
-			} else {
-				log.Debug("Process is stopped, restarting.")
-				s.supervisorChannel <- supervisorRestart
-			}
-
-			log.Debug("Stop keepaliver.")
-			return
-		}
-		time.Sleep(timeoutSupervising)
-	}
-}
-
-// getExitCode has to be executed if no real supervising is performed. It just
-// returns the command exit code.
-func (s *supervisor) getExitCode() {
-	for {
-		if s.stopped() {
-			s.exitCodeChannel <- s.cmd.ExitCode()
-		}
-		time.Sleep(timeoutSupervising)
-	}
+// waitForExit is just a function to be executed in goroutine. It waits
+// for the exit of the command and sends it into exitChannel.
+func (s *supervisor) waitForExit(cmd *command) {
+	<-cmd.Done()
+	log.WithFields(log.Fields{
+		"cmd":      cmd,
+		"exitCode": cmd.ExitCode(),
+	}).Debug("Process is exited.")
+	s.exitChannel <- cmd
 }
 
 // newSupervisor returns new supervisor structure based on the given arguments.
 // No command execution is performed at that moment.
-func newSupervisor(command []string,
+func newSupervisor(commandToExecute []string,
+	environ func() []string,
 	exitCodeChannel chan int,
 	gracefulSignal os.Signal,
 	gracefulTimeout time.Duration,
 	hasTTY bool,
-	restartOnFailures bool,
+	healthChecks []options.HealthCheck,
+	mode options.SupervisorMode,
+	notify options.Notify,
+	systemd *systemdNotifier,
+	policy options.RestartPolicy,
 	supervisorChannel chan supervisorAction,
-	allowedExitCodes map[int]bool) *supervisor {
+	restart options.Restart) *supervisor {
 	return &supervisor{
-		allowedExitCodes:  allowedExitCodes,
-		command:           command,
+		command:           commandToExecute,
+		environ:           environ,
+		exitChannel:       make(chan *command, 1),
 		exitCodeChannel:   exitCodeChannel,
 		gracefulSignal:    gracefulSignal,
 		gracefulTimeout:   gracefulTimeout,
 		hasTTY:            hasTTY,
-		keepAlivers:       new(sync.WaitGroup),
-		restartOnFailures: restartOnFailures,
+		healthChecks:      healthChecks,
+		mode:              mode,
+		notify:            notify,
+		notifyChannel:     make(chan map[string]string, 1),
+		policy:            policy,
+		random:            rand.New(rand.NewSource(time.Now().UnixNano())),
+		restart:           restart,
+		restartChannel:    make(chan *command, 1),
 		supervisorChannel: supervisorChannel,
+		systemd:           systemd,
+		unhealthyChannel:  make(chan *command, 1),
 	}
 }
@@ -0,0 +1,104 @@
+package execution
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	assert "github.com/stretchr/testify/assert"
+
+	opts "github.com/9seconds/guidedog/internal/options"
+)
+
+func createSupervisor(script string, mode opts.SupervisorMode) *supervisor {
+	return newSupervisor([]string{"sh", "-c", script},
+		os.Environ,
+		make(chan int, 1),
+		syscall.SIGTERM,
+		time.Second,
+		false,
+		nil,
+		mode,
+		opts.Notify{},
+		nil,
+		opts.RestartPolicy{},
+		make(chan supervisorAction, 1),
+		opts.Restart{})
+}
+
+func receivedExitCode(s *supervisor, timeout time.Duration) (int, bool) {
+	select {
+	case exitCode := <-s.exitCodeChannel:
+		return exitCode, true
+	case <-time.After(timeout):
+		return 0, false
+	}
+}
+
+func TestSupervisorExitedPublishesExitCode(t *testing.T) {
+	s := createSupervisor("exit 3", opts.SupervisorModeNone)
+	s.Start()
+
+	s.Exited(<-s.exitChannel)
+
+	exitCode, ok := receivedExitCode(s, time.Second)
+	assert.True(t, ok)
+	assert.Equal(t, exitCode, 3)
+}
+
+func TestSupervisorExitedReplacedCommand(t *testing.T) {
+	s := createSupervisor("exec sleep 10", opts.SupervisorModeAlways|opts.SupervisorModeRestarting)
+	s.Start()
+	replaced := s.cmd
+
+	s.Signal(supervisorRestart)
+	current := s.cmd
+	assert.NotEqual(t, replaced, current)
+
+	s.Exited(<-s.exitChannel)
+	assert.Equal(t, s.cmd, current)
+	assert.Equal(t, s.restarts, 0)
+	assert.False(t, current.Stopped())
+	_, ok := receivedExitCode(s, 100*time.Millisecond)
+	assert.False(t, ok)
+
+	s.Signal(supervisorStop)
+	exitCode, ok := receivedExitCode(s, time.Second)
+	assert.True(t, ok)
+	assert.Equal(t, exitCode, current.ExitCode())
+}
+
+func TestSupervisorRestartDelayedReplacedCommand(t *testing.T) {
+	s := createSupervisor("exec sleep 10", opts.SupervisorModeAlways|opts.SupervisorModeRestarting)
+	s.Start()
+	replaced := s.cmd
+
+	s.Signal(supervisorRestart)
+	current := s.cmd
+
+	s.RestartDelayed(replaced)
+	assert.Equal(t, s.cmd, current)
+	assert.False(t, current.Stopped())
+
+	s.Signal(supervisorStop)
+	_, ok := receivedExitCode(s, time.Second)
+	assert.True(t, ok)
+}
+
+func TestSupervisorExitedWhileStopping(t *testing.T) {
+	s := createSupervisor("exec sleep 10", opts.SupervisorModeAlways|opts.SupervisorModeRestarting)
+	s.Start()
+	stopped := s.cmd
+
+	s.Signal(supervisorStop)
+	_, ok := receivedExitCode(s, time.Second)
+	assert.True(t, ok)
+
+	s.Exited(<-s.exitChannel)
+	s.RestartDelayed(stopped)
+	assert.Equal(t, s.cmd, stopped)
+	assert.Equal(t, s.restarts, 0)
+	_, ok = receivedExitCode(s, 100*time.Millisecond)
+	assert.False(t, ok)
+}
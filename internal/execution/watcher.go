@@ -11,8 +11,8 @@ import (
 	environment "github.com/9seconds/guidedog/internal/environment"
 )
 
-// makeWatcher starts to track given paths and sends filesystem notifications
-// into channel.
+// makeWatcher starts to track given paths and paths which affect
+// environment and sends filesystem notifications into channel.
 func makeWatcher(paths []string, env *environment.Environment) (channel chan bool) {
 	channel = make(chan bool, 1)
 
@@ -21,21 +21,30 @@ func makeWatcher(paths []string, env *environment.Environment) (channel chan boo
 		panic(err)
 	}
 
-	trackPaths := make([]string, 0, len(paths))
-	for _, value := range paths {
-		if value != "" {
-			trackPaths = append(trackPaths, value)
-		}
-	}
-
-	if len(trackPaths) == 0 {
+	if !watchPaths(watcher, append(env.TrackedPaths(), paths...)) {
 		return
 	}
 
-	for _, path := range trackPaths {
-		log.WithField("path", path).Info("Add path")
-		err = watcher.Add(path)
-		if err != nil {
+	go watcherLoop(env, paths, channel, watcher)
+
+	return
+}
+
+// watchPaths adds given paths to the watcher. Paths which are watched
+// already are just re-added: files with secrets are usually replaced by
+// renaming so inode may be changed. Returns false if there are no paths
+// to watch.
+func watchPaths(watcher *fsnotify.Watcher, paths []string) bool {
+	hasPaths := false
+
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		hasPaths = true
+
+		log.WithField("path", path).Debug("Add path")
+		if err := watcher.Add(path); err != nil {
 			log.WithFields(log.Fields{
 				"path":  path,
 				"error": err,
@@ -43,15 +52,17 @@ func makeWatcher(paths []string, env *environment.Environment) (channel chan boo
 		}
 	}
 
-	go watcherLoop(env, channel, watcher)
-
-	return
+	return hasPaths
 }
 
-// watcherLoop defines main watcher loop.
-func watcherLoop(env *environment.Environment, channel chan bool, watcher *fsnotify.Watcher) {
+// watcherLoop defines main watcher loop. Restart notification is sent
+// only if environment or content of tracked paths is changed: events
+// which do not change content of files (like chmod or touch) are skipped.
+func watcherLoop(env *environment.Environment, paths []string, channel chan bool, watcher *fsnotify.Watcher) {
 	defer watcher.Close()
 
+	hashes := hashPaths(append(env.TrackedPaths(), paths...))
+
 	for {
 		select {
 		case event, ok := <-watcher.Events:
@@ -68,7 +79,40 @@ func watcherLoop(env *environment.Environment, channel chan bool, watcher *fsnot
 				"op":    event.Op,
 			}).Info("Event from filesystem is coming")
 
-			env.Update()
+			// Files which are replaced by renaming lose their watches even
+			// if content is the same so paths are re-added on every event.
+			watchPaths(watcher, append(env.TrackedPaths(), paths...))
+
+			newHashes := hashPaths(append(env.TrackedPaths(), paths...))
+			changedPaths := changedKeys(hashes, newHashes)
+			hashes = newHashes
+
+			if len(changedPaths) == 0 {
+				log.WithField("event", event).Debug("Content of tracked paths is not changed, skip.")
+				continue
+			}
+
+			variables := env.Variables()
+			restart, _ := reloadEnvironment(env)
+			watchPaths(watcher, append(env.TrackedPaths(), paths...))
+
+			if !restart {
+				continue
+			}
+
+			// Failed reload keeps the current environment so only changes
+			// of tracked paths may restart the command.
+			changedVariables := changedKeys(variables, env.Variables())
+			changedTrackedPaths := filterPaths(changedPaths, paths)
+			if len(changedVariables) == 0 && len(changedTrackedPaths) == 0 {
+				log.WithField("changedPaths", changedPaths).Info("Environment and tracked paths are not changed, skip restart.")
+				continue
+			}
+
+			log.WithFields(log.Fields{
+				"changedPaths":     changedPaths,
+				"changedVariables": changedVariables,
+			}).Info("Environment or tracked paths are changed.")
 
 			if len(channel) == 0 {
 				channel <- true
@@ -0,0 +1,79 @@
+package execution
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	assert "github.com/stretchr/testify/assert"
+
+	opts "github.com/9seconds/guidedog/internal/options"
+)
+
+func receivedEvent(channel chan bool, timeout time.Duration) bool {
+	select {
+	case <-channel:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+func TestWatcherTracksReplacedFiles(t *testing.T) {
+	tempDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(tempDir)
+
+	trackedName := filepath.Join(tempDir, "tracked")
+	replacementName := filepath.Join(tempDir, "replacement")
+	ioutil.WriteFile(trackedName, []byte("content"), os.FileMode(0666))
+
+	channel := makeWatcher([]string{trackedName}, createEnvironment(t))
+
+	ioutil.WriteFile(replacementName, []byte("content"), os.FileMode(0666))
+	assert.Nil(t, os.Rename(replacementName, trackedName))
+	assert.False(t, receivedEvent(channel, 300*time.Millisecond))
+
+	ioutil.WriteFile(trackedName, []byte("changed"), os.FileMode(0666))
+	assert.True(t, receivedEvent(channel, 2*time.Second))
+}
+
+func TestWatcherSkipsFailedStrictReload(t *testing.T) {
+	tempDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(tempDir)
+
+	filename := filepath.Join(tempDir, "config.json")
+	ioutil.WriteFile(filename, []byte(`{"FOO": "bar"}`), os.FileMode(0666))
+
+	env := createReloadEnvironment(t, filename, true, "", opts.Schema{})
+	channel := makeWatcher([]string{}, env)
+
+	ioutil.WriteFile(filename, []byte(`{"FOO": `), os.FileMode(0666))
+	assert.False(t, receivedEvent(channel, 300*time.Millisecond))
+	assert.Equal(t, env.Variables()["FOO"], "bar")
+
+	ioutil.WriteFile(filename, []byte(`{"FOO": "baz"}`), os.FileMode(0666))
+	assert.True(t, receivedEvent(channel, 2*time.Second))
+	assert.Equal(t, env.Variables()["FOO"], "baz")
+}
+
+func TestWatcherSkipsFailedReload(t *testing.T) {
+	tempDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(tempDir)
+
+	filename := filepath.Join(tempDir, "config.json")
+	trackedName := filepath.Join(tempDir, "tracked")
+	ioutil.WriteFile(filename, []byte(`{"FOO": "bar"}`), os.FileMode(0666))
+	ioutil.WriteFile(trackedName, []byte("content"), os.FileMode(0666))
+
+	env := createReloadEnvironment(t, filename, false, "", opts.Schema{})
+	channel := makeWatcher([]string{trackedName}, env)
+
+	ioutil.WriteFile(filename, []byte(`{"FOO": `), os.FileMode(0666))
+	assert.False(t, receivedEvent(channel, 300*time.Millisecond))
+	assert.Equal(t, env.Variables()["FOO"], "bar")
+
+	ioutil.WriteFile(trackedName, []byte("changed"), os.FileMode(0666))
+	assert.True(t, receivedEvent(channel, 2*time.Second))
+}
@@ -0,0 +1,29 @@
+// Package options defines common options set for the guide-dog app.
+package options
+
+import (
+	"fmt"
+	"time"
+)
+
+// ExecFormat is a prefix of config sources which are commands printing
+// KEY=VALUE lines (e.g. "exec:./metadata.sh").
+const ExecFormat = "exec"
+
+// ExecFormatSuffix marks config sources which are commands printing the
+// config of the given format (e.g. "json+exec:vault-env --json").
+const ExecFormatSuffix = "+" + ExecFormat
+
+// WithExecInterval sets how often commands of config sources are re-run.
+// Zero interval means that commands are run only on startup and on
+// reloads.
+func WithExecInterval(interval time.Duration) Option {
+	return func(options *Options) error {
+		if interval < 0 {
+			return fmt.Errorf("Negative interval %s", interval)
+		}
+		options.ExecInterval = interval
+
+		return nil
+	}
+}
@@ -2,10 +2,40 @@
 package options
 
 import (
+	"bufio"
+	"bytes"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
 	"strings"
 )
 
+// configFormatSniffSize defines how many bytes of the config are read to
+// detect its format.
+const configFormatSniffSize = 4096
+
+// configFormatExtensions maps file extensions to config formats.
+var configFormatExtensions = map[string]ConfigFormat{
+	".json":       ConfigFormatJSON,
+	".yaml":       ConfigFormatYAML,
+	".yml":        ConfigFormatYAML,
+	".ini":        ConfigFormatINI,
+	".env":        ConfigFormatDotEnv,
+	".toml":       ConfigFormatTOML,
+	".properties": ConfigFormatProperties,
+}
+
+// configFormatSniffers define regular expressions for the lines which
+// are specific for config formats.
+var (
+	configFormatSniffSection   = regexp.MustCompile(`^\[[^\]]+\]$`)
+	configFormatSniffTOMLValue = regexp.MustCompile(`^[^=]+=\s*("|'|\[|\{|[0-9+-]|true$|false$)`)
+	configFormatSniffDotEnv    = regexp.MustCompile(`^(export\s+)?[A-Za-z_][A-Za-z0-9_]*\s*=`)
+	configFormatSniffYAML      = regexp.MustCompile(`^[^\s:=]+\s*:(\s|$)`)
+)
+
 // ConfigFormat defines the type of the config on the given
 // path. Please check ConfigFormat* constants family for the possible
 // values.
@@ -19,6 +49,9 @@ const (
 	ConfigFormatYAML
 	ConfigFormatINI
 	ConfigFormatEnvDir
+	ConfigFormatDotEnv
+	ConfigFormatTOML
+	ConfigFormatProperties
 )
 
 func (cf ConfigFormat) String() string {
@@ -33,6 +66,12 @@ func (cf ConfigFormat) String() string {
 		return "ini"
 	case ConfigFormatEnvDir:
 		return "envdir"
+	case ConfigFormatDotEnv:
+		return "dotenv"
+	case ConfigFormatTOML:
+		return "toml"
+	case ConfigFormatProperties:
+		return "properties"
 	default:
 		return "ERROR"
 	}
@@ -52,9 +91,106 @@ func parseConfigFormat(name string) (format ConfigFormat, err error) {
 		format = ConfigFormatINI
 	case "envdir":
 		format = ConfigFormatEnvDir
+	case "dotenv":
+		format = ConfigFormatDotEnv
+	case "toml":
+		format = ConfigFormatTOML
+	case "properties":
+		format = ConfigFormatProperties
 	default:
 		err = fmt.Errorf("Unknown config format %s", name)
 	}
 
 	return
 }
+
+// isContentFormat checks if config of the given format is stored in a
+// single file so it may be parsed from any content (decrypted config or
+// output of the command).
+func isContentFormat(format ConfigFormat) bool {
+	switch format {
+	case ConfigFormatJSON, ConfigFormatYAML, ConfigFormatINI, ConfigFormatDotEnv, ConfigFormatTOML, ConfigFormatProperties:
+		return true
+	default:
+		return false
+	}
+}
+
+// detectConfigFormat detects the format of the config on the given path.
+// Directories are envdirs, files are detected by their extensions. If
+// extension is unknown, content of the file is sniffed.
+func detectConfigFormat(path string) (format ConfigFormat, err error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+
+	if stat.IsDir() {
+		return ConfigFormatEnvDir, nil
+	}
+
+	if format, ok := configFormatExtensions[strings.ToLower(filepath.Ext(path))]; ok {
+		return format, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	content := make([]byte, configFormatSniffSize)
+	size, err := io.ReadFull(file, content)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return
+	}
+
+	if format, ok := sniffConfigFormat(content[:size]); ok {
+		return format, nil
+	}
+
+	return ConfigFormatNone, fmt.Errorf("Cannot detect format of config %s, please set it explicitly", path)
+}
+
+// sniffConfigFormat guesses the format of config by its content.
+func sniffConfigFormat(content []byte) (ConfigFormat, bool) {
+	content = bytes.TrimSpace(bytes.TrimPrefix(content, []byte("\xef\xbb\xbf")))
+
+	switch {
+	case bytes.HasPrefix(content, []byte("{")):
+		return ConfigFormatJSON, true
+	case bytes.HasPrefix(content, []byte("---")):
+		return ConfigFormatYAML, true
+	}
+
+	lines := make([]string, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") && !strings.HasPrefix(line, ";") {
+			lines = append(lines, line)
+		}
+	}
+
+	if len(lines) == 0 {
+		return ConfigFormatNone, false
+	}
+
+	switch {
+	case strings.HasPrefix(lines[0], "[["):
+		return ConfigFormatTOML, true
+	case configFormatSniffSection.MatchString(lines[0]):
+		for _, line := range lines[1:] {
+			if !configFormatSniffSection.MatchString(line) && !configFormatSniffTOMLValue.MatchString(line) {
+				return ConfigFormatINI, true
+			}
+		}
+		return ConfigFormatTOML, true
+	case configFormatSniffDotEnv.MatchString(lines[0]):
+		return ConfigFormatDotEnv, true
+	case configFormatSniffYAML.MatchString(lines[0]):
+		return ConfigFormatYAML, true
+	}
+
+	return ConfigFormatNone, false
+}
@@ -1,6 +1,9 @@
 package options
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -8,9 +11,10 @@ import (
 )
 
 func TestParseConfigFormat(t *testing.T) {
-	validNames := []string{"", "none", "json", "yaml", "ini", "envdir"}
+	validNames := []string{"", "none", "json", "yaml", "ini", "envdir", "dotenv", "toml", "properties"}
 	formats := []ConfigFormat{ConfigFormatNone, ConfigFormatNone,
-		ConfigFormatJSON, ConfigFormatYAML, ConfigFormatINI, ConfigFormatEnvDir}
+		ConfigFormatJSON, ConfigFormatYAML, ConfigFormatINI, ConfigFormatEnvDir,
+		ConfigFormatDotEnv, ConfigFormatTOML, ConfigFormatProperties}
 
 	for idx, name := range validNames {
 		for _, caseSensitiveName := range []string{name, strings.ToUpper(name)} {
@@ -32,4 +36,83 @@ func TestConfigFormatNames(t *testing.T) {
 	assert.Equal(t, ConfigFormatYAML.String(), "yaml")
 	assert.Equal(t, ConfigFormatINI.String(), "ini")
 	assert.Equal(t, ConfigFormatEnvDir.String(), "envdir")
+	assert.Equal(t, ConfigFormatDotEnv.String(), "dotenv")
+	assert.Equal(t, ConfigFormatTOML.String(), "toml")
+	assert.Equal(t, ConfigFormatProperties.String(), "properties")
+}
+
+func TestDetectConfigFormatByExtension(t *testing.T) {
+	tempDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(tempDir)
+
+	extensions := map[string]ConfigFormat{
+		"config.json":    ConfigFormatJSON,
+		"config.yaml":    ConfigFormatYAML,
+		"config.YML":     ConfigFormatYAML,
+		"config.ini":     ConfigFormatINI,
+		".env":           ConfigFormatDotEnv,
+		"prod.env":       ConfigFormatDotEnv,
+		"config.toml":    ConfigFormatTOML,
+		"app.properties": ConfigFormatProperties,
+	}
+
+	for name, expected := range extensions {
+		path := filepath.Join(tempDir, name)
+		ioutil.WriteFile(path, []byte(""), os.FileMode(0666))
+
+		format, err := detectConfigFormat(path)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, format)
+	}
+}
+
+func TestDetectConfigFormatDirectory(t *testing.T) {
+	tempDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(tempDir)
+
+	format, err := detectConfigFormat(tempDir)
+	assert.Nil(t, err)
+	assert.Equal(t, ConfigFormatEnvDir, format)
+}
+
+func TestDetectConfigFormatByContent(t *testing.T) {
+	tempDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(tempDir)
+
+	contents := map[string]ConfigFormat{
+		"{\"key\": 1}":                         ConfigFormatJSON,
+		"---\nkey: 1":                          ConfigFormatYAML,
+		"# comment\nkey: value\n":              ConfigFormatYAML,
+		"; comment\n[section]\nkey = value":    ConfigFormatINI,
+		"[table]\nkey = \"value\"\nnum = 1":    ConfigFormatTOML,
+		"[[servers]]\nhost = \"a\"":            ConfigFormatTOML,
+		"KEY=value\nOTHER=\"quoted\"":          ConfigFormatDotEnv,
+		"export KEY=value":                     ConfigFormatDotEnv,
+		"\xef\xbb\xbf  \n\n{\"key\": \"bom\"}": ConfigFormatJSON,
+	}
+
+	for content, expected := range contents {
+		path := filepath.Join(tempDir, "config")
+		ioutil.WriteFile(path, []byte(content), os.FileMode(0666))
+
+		format, err := detectConfigFormat(path)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, format, content)
+	}
+}
+
+func TestDetectConfigFormatFails(t *testing.T) {
+	tempDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(tempDir)
+
+	_, err := detectConfigFormat(filepath.Join(tempDir, "absent"))
+	assert.NotNil(t, err)
+
+	for _, content := range []string{"", "# only comment", "just some text"} {
+		path := filepath.Join(tempDir, "config")
+		ioutil.WriteFile(path, []byte(content), os.FileMode(0666))
+
+		_, err = detectConfigFormat(path)
+		assert.NotNil(t, err)
+	}
 }
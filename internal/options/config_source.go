@@ -0,0 +1,103 @@
+// Package options defines common options set for the guide-dog app.
+package options
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ConfigSourceSeparator separates format from path in config source
+// definitions like "yaml:/etc/base.yaml".
+const ConfigSourceSeparator = ":"
+
+// ConfigSource defines a single source of environment variables: a path
+// to the config, its format and if config is encrypted. If Exec is set,
+// Path is a shell command which prints the config to stdout.
+type ConfigSource struct {
+	Encrypted bool
+	Exec      bool
+	Format    ConfigFormat
+	Path      string
+}
+
+func (cs ConfigSource) String() string {
+	format := cs.Format.String()
+	switch {
+	case cs.Encrypted:
+		format += EncryptedFormatSuffix
+	case cs.Exec:
+		format += ExecFormatSuffix
+	}
+
+	return format + ConfigSourceSeparator + cs.Path
+}
+
+// parseConfigSource parses config source definition. Definition is a path
+// optionally prefixed by format (e.g. "yaml:/etc/base.yaml"). If format is
+// omitted, defaultFormat is used. If defaultFormat is empty, format is
+// detected by the path. Encrypted configs are marked by "+enc" suffix of
+// the format (e.g. "yaml+enc:/etc/secrets.yaml") or by ".enc" extension if
+// format is omitted. Commands are marked by "+exec" suffix of the format
+// (e.g. "json+exec:vault-env --json") or by "exec" prefix for KEY=VALUE
+// output (e.g. "exec:./metadata.sh").
+func parseConfigSource(definition string, defaultFormat string) (source ConfigSource, err error) {
+	source = ConfigSource{Path: definition}
+	formatName := defaultFormat
+	explicitFormat := false
+
+	split := strings.SplitN(definition, ConfigSourceSeparator, 2)
+	if len(split) == 2 && split[0] != "" {
+		prefix := split[0]
+		encrypted, execute := false, false
+
+		switch lowerPrefix := strings.ToLower(prefix); {
+		case lowerPrefix == ExecFormat:
+			prefix = ConfigFormatDotEnv.String()
+			execute = true
+		case strings.HasSuffix(lowerPrefix, EncryptedFormatSuffix):
+			prefix = prefix[:len(prefix)-len(EncryptedFormatSuffix)]
+			encrypted = true
+		case strings.HasSuffix(lowerPrefix, ExecFormatSuffix):
+			prefix = prefix[:len(prefix)-len(ExecFormatSuffix)]
+			execute = true
+		}
+
+		if _, formatErr := parseConfigFormat(prefix); formatErr == nil {
+			source.Path = split[1]
+			source.Encrypted = encrypted
+			source.Exec = execute
+			formatName = prefix
+			explicitFormat = true
+		}
+	}
+
+	if source.Path == "" {
+		return source, fmt.Errorf("Empty path in config source %s", definition)
+	}
+
+	if !explicitFormat {
+		source.Encrypted = strings.ToLower(filepath.Ext(source.Path)) == EncryptedExtension
+	}
+
+	switch {
+	case formatName != "":
+		source.Format, err = parseConfigFormat(formatName)
+	case source.Encrypted:
+		source.Format, err = detectEncryptedConfigFormat(source.Path)
+	default:
+		source.Format, err = detectConfigFormat(source.Path)
+	}
+	if err != nil {
+		return
+	}
+
+	switch {
+	case source.Encrypted && !isContentFormat(source.Format):
+		err = fmt.Errorf("Config format %s cannot be encrypted", source.Format)
+	case source.Exec && !isContentFormat(source.Format):
+		err = fmt.Errorf("Config format %s cannot be read from command output", source.Format)
+	}
+
+	return
+}
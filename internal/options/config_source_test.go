@@ -0,0 +1,112 @@
+package options
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestParseConfigSource(t *testing.T) {
+	source, err := parseConfigSource("yaml:/etc/base.yaml", "json")
+	assert.Nil(t, err)
+	assert.Equal(t, source, ConfigSource{Format: ConfigFormatYAML, Path: "/etc/base.yaml"})
+
+	source, err = parseConfigSource("ENVDIR:/etc/env", "json")
+	assert.Nil(t, err)
+	assert.Equal(t, source, ConfigSource{Format: ConfigFormatEnvDir, Path: "/etc/env"})
+
+	source, err = parseConfigSource("/etc/base.json", "json")
+	assert.Nil(t, err)
+	assert.Equal(t, source, ConfigSource{Format: ConfigFormatJSON, Path: "/etc/base.json"})
+
+	source, err = parseConfigSource("weird:name", "ini")
+	assert.Nil(t, err)
+	assert.Equal(t, source, ConfigSource{Format: ConfigFormatINI, Path: "weird:name"})
+
+	source, err = parseConfigSource(":name", "ini")
+	assert.Nil(t, err)
+	assert.Equal(t, source, ConfigSource{Format: ConfigFormatINI, Path: ":name"})
+}
+
+func TestParseEmptyConfigSource(t *testing.T) {
+	_, err := parseConfigSource("", "json")
+	assert.NotNil(t, err)
+
+	_, err = parseConfigSource("yaml:", "json")
+	assert.NotNil(t, err)
+}
+
+func TestConfigSourceString(t *testing.T) {
+	source := ConfigSource{Format: ConfigFormatYAML, Path: "/etc/base.yaml"}
+	assert.Equal(t, source.String(), "yaml:/etc/base.yaml")
+}
+
+func TestParseConfigSourceDetectsFormat(t *testing.T) {
+	tempDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(tempDir)
+
+	path := filepath.Join(tempDir, "config.yml")
+	ioutil.WriteFile(path, []byte("key: value"), os.FileMode(0666))
+
+	source, err := parseConfigSource(path, "")
+	assert.Nil(t, err)
+	assert.Equal(t, source, ConfigSource{Format: ConfigFormatYAML, Path: path})
+
+	source, err = parseConfigSource("json:"+path, "")
+	assert.Nil(t, err)
+	assert.Equal(t, source, ConfigSource{Format: ConfigFormatJSON, Path: path})
+
+	_, err = parseConfigSource(filepath.Join(tempDir, "absent.yml"), "")
+	assert.NotNil(t, err)
+}
+
+func TestParseEncryptedConfigSource(t *testing.T) {
+	source, err := parseConfigSource("yaml+enc:/etc/secrets", "")
+	assert.Nil(t, err)
+	assert.Equal(t, source, ConfigSource{Encrypted: true, Format: ConfigFormatYAML, Path: "/etc/secrets"})
+	assert.Equal(t, source.String(), "yaml+enc:/etc/secrets")
+
+	source, err = parseConfigSource("/etc/secrets.env.enc", "")
+	assert.Nil(t, err)
+	assert.Equal(t, source, ConfigSource{Encrypted: true, Format: ConfigFormatDotEnv, Path: "/etc/secrets.env.enc"})
+
+	source, err = parseConfigSource("/etc/secrets.enc", "json")
+	assert.Nil(t, err)
+	assert.Equal(t, source, ConfigSource{Encrypted: true, Format: ConfigFormatJSON, Path: "/etc/secrets.enc"})
+
+	_, err = parseConfigSource("/etc/secrets.enc", "")
+	assert.NotNil(t, err)
+
+	_, err = parseConfigSource("envdir+enc:/etc/env", "")
+	assert.NotNil(t, err)
+}
+
+func TestWithDecryptionKey(t *testing.T) {
+	options := &Options{ConfigSources: []ConfigSource{{Encrypted: true, Format: ConfigFormatJSON, Path: "/etc/secrets"}}}
+	assert.NotNil(t, WithDecryptionKey("")(options))
+	assert.Nil(t, WithDecryptionKey("/etc/key")(options))
+	assert.Equal(t, options.DecryptionKey, "/etc/key")
+
+	options = &Options{ConfigSources: []ConfigSource{{Format: ConfigFormatJSON, Path: "/etc/config"}}}
+	assert.Nil(t, WithDecryptionKey("")(options))
+}
+
+func TestParseExecConfigSource(t *testing.T) {
+	source, err := parseConfigSource("json+exec:vault-env --json", "")
+	assert.Nil(t, err)
+	assert.Equal(t, source, ConfigSource{Exec: true, Format: ConfigFormatJSON, Path: "vault-env --json"})
+	assert.Equal(t, source.String(), "json+exec:vault-env --json")
+
+	source, err = parseConfigSource("exec:./metadata.sh --url http://host/", "json")
+	assert.Nil(t, err)
+	assert.Equal(t, source, ConfigSource{Exec: true, Format: ConfigFormatDotEnv, Path: "./metadata.sh --url http://host/"})
+
+	_, err = parseConfigSource("envdir+exec:ls", "")
+	assert.NotNil(t, err)
+
+	_, err = parseConfigSource("exec:", "")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,46 @@
+// Package options defines common options set for the guide-dog app.
+package options
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// EncryptedFormatSuffix marks encrypted config sources in definitions
+// like "yaml+enc:/etc/secrets.yaml.enc".
+const EncryptedFormatSuffix = "+enc"
+
+// EncryptedExtension is an extension of encrypted configs. Format of such
+// configs is detected by the extension before it (config.yaml.enc is
+// encrypted YAML).
+const EncryptedExtension = ".enc"
+
+// detectEncryptedConfigFormat detects the format of encrypted config by the
+// extension which is placed before EncryptedExtension. Content cannot be
+// sniffed so extension is mandatory.
+func detectEncryptedConfigFormat(path string) (ConfigFormat, error) {
+	innerPath := path[:len(path)-len(EncryptedExtension)]
+	if format, ok := configFormatExtensions[strings.ToLower(filepath.Ext(innerPath))]; ok {
+		return format, nil
+	}
+
+	return ConfigFormatNone, fmt.Errorf("Cannot detect format of encrypted config %s, please set it explicitly", path)
+}
+
+// WithDecryptionKey sets the path to the key file which is used to decrypt
+// encrypted configs. Key is mandatory if any config source is encrypted.
+func WithDecryptionKey(path string) Option {
+	return func(options *Options) error {
+		if path == "" {
+			for _, source := range options.ConfigSources {
+				if source.Encrypted {
+					return fmt.Errorf("Config %s is encrypted but decryption key is not set", source.Path)
+				}
+			}
+		}
+		options.DecryptionKey = path
+
+		return nil
+	}
+}
@@ -0,0 +1,71 @@
+// Package options defines common options set for the guide-dog app.
+package options
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EnvDirMode defines how files of envdirs are read. Please check
+// EnvDirMode* constants family for the possible values.
+type EnvDirMode uint8
+
+// EnvDirMode* consts family defines possible ways to read envdirs.
+// EnvDirModeDaemontools follows envdir of daemontools: only the first
+// line is used, trailing spaces are trimmed, NUL bytes are converted into
+// newlines, empty files remove variables and dot-files are skipped.
+// EnvDirModeCompat uses the whole trimmed content of files and sets empty
+// files to empty strings.
+const (
+	EnvDirModeDaemontools EnvDirMode = iota
+	EnvDirModeCompat
+)
+
+func (em EnvDirMode) String() string {
+	switch em {
+	case EnvDirModeDaemontools:
+		return "daemontools"
+	case EnvDirModeCompat:
+		return "compat"
+	default:
+		return "ERROR"
+	}
+}
+
+func parseEnvDirMode(name string) (mode EnvDirMode, err error) {
+	switch strings.ToLower(name) {
+	case "daemontools":
+		mode = EnvDirModeDaemontools
+	case "compat":
+		mode = EnvDirModeCompat
+	default:
+		err = fmt.Errorf("Unknown envdir mode %s", name)
+	}
+
+	return
+}
+
+// EnvDir defines how envdirs are read. If Recursive is set, nested
+// directories are read too and names of variables are composed from their
+// paths (envdir/DB/HOST becomes DB_HOST according to Naming).
+type EnvDir struct {
+	Mode      EnvDirMode
+	Recursive bool
+}
+
+// WithEnvDir sets the way envdirs are read.
+func WithEnvDir(mode string, recursive bool) Option {
+	return func(options *Options) error {
+		convertedMode, err := parseEnvDirMode(mode)
+		if err != nil {
+			return err
+		}
+
+		options.EnvDir = EnvDir{
+			Mode:      convertedMode,
+			Recursive: recursive,
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,34 @@
+package options
+
+import (
+	"testing"
+
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestEnvDirModeString(t *testing.T) {
+	assert.Equal(t, EnvDirModeDaemontools.String(), "daemontools")
+	assert.Equal(t, EnvDirModeCompat.String(), "compat")
+	assert.Equal(t, EnvDirMode(0xFF).String(), "ERROR")
+}
+
+func TestParseEnvDirMode(t *testing.T) {
+	mode, err := parseEnvDirMode("Daemontools")
+	assert.Nil(t, err)
+	assert.Equal(t, mode, EnvDirModeDaemontools)
+
+	mode, err = parseEnvDirMode("compat")
+	assert.Nil(t, err)
+	assert.Equal(t, mode, EnvDirModeCompat)
+
+	_, err = parseEnvDirMode("runit")
+	assert.NotNil(t, err)
+}
+
+func TestWithEnvDir(t *testing.T) {
+	options := &Options{}
+	assert.Nil(t, WithEnvDir("compat", true)(options))
+	assert.Equal(t, options.EnvDir, EnvDir{Mode: EnvDirModeCompat, Recursive: true})
+
+	assert.NotNil(t, WithEnvDir("unknown", false)(options))
+}
@@ -0,0 +1,115 @@
+// Package options defines common options set for the guide-dog app.
+package options
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// ExitStatusSeparator separates items of exit status definitions like
+// "0,3-5,SIGKILL".
+const ExitStatusSeparator = ","
+
+// exitCodeRangeSeparator separates bounds of exit code ranges like "3-5".
+const exitCodeRangeSeparator = "-"
+
+// maxExitCode is the maximal exit code process may have.
+const maxExitCode = 255
+
+// ExitStatus defines how command has exited: with exit code or killed by
+// signal. Signal is 0 if command has exited by itself.
+type ExitStatus struct {
+	Code   int
+	Signal syscall.Signal
+}
+
+// Failed tells if command has exited with non-zero code or killed by
+// signal.
+func (es ExitStatus) Failed() bool {
+	return es.Signal != 0 || es.Code != 0
+}
+
+// ExitCodeRange defines the inclusive range of exit codes.
+type ExitCodeRange struct {
+	From int
+	To   int
+}
+
+// ExitStatusSet defines the set of exit statuses: ranges of exit codes and
+// signals which killed command.
+type ExitStatusSet struct {
+	Codes   []ExitCodeRange
+	Signals []syscall.Signal
+}
+
+// Matches checks if exit status belongs to the set. Commands killed by
+// signal are matched only by signals, others only by exit codes.
+func (set ExitStatusSet) Matches(status ExitStatus) bool {
+	if status.Signal != 0 {
+		for _, signal := range set.Signals {
+			if signal == status.Signal {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, codes := range set.Codes {
+		if codes.From <= status.Code && status.Code <= codes.To {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseExitStatusSet parses comma-separated definition of exit statuses.
+// Each item is exit code ("1"), range of exit codes ("3-5") or signal name
+// ("SIGKILL" or "kill").
+func parseExitStatusSet(definition string) (set ExitStatusSet, err error) {
+	for _, item := range strings.Split(definition, ExitStatusSeparator) {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			return set, fmt.Errorf("Empty exit status in %s", definition)
+		}
+
+		if item[0] < '0' || item[0] > '9' {
+			signal, err := parseSignalName(item)
+			if err != nil {
+				return set, err
+			}
+			set.Signals = append(set.Signals, signal)
+			continue
+		}
+
+		codes, err := parseExitCodeRange(item)
+		if err != nil {
+			return set, err
+		}
+		set.Codes = append(set.Codes, codes)
+	}
+
+	return
+}
+
+// parseExitCodeRange parses exit code ("1") or range of exit codes ("3-5").
+func parseExitCodeRange(definition string) (codes ExitCodeRange, err error) {
+	split := strings.SplitN(definition, exitCodeRangeSeparator, 2)
+	if codes.From, err = strconv.Atoi(split[0]); err != nil {
+		return codes, fmt.Errorf("Incorrect exit code %s", definition)
+	}
+	codes.To = codes.From
+	if len(split) == 2 {
+		if codes.To, err = strconv.Atoi(split[1]); err != nil {
+			return codes, fmt.Errorf("Incorrect exit code range %s", definition)
+		}
+	}
+
+	if codes.From > codes.To || codes.To > maxExitCode {
+		err = fmt.Errorf("Incorrect exit code range %s", definition)
+	}
+
+	return
+}
@@ -0,0 +1,41 @@
+package options
+
+import (
+	"syscall"
+	"testing"
+
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestExitStatusFailed(t *testing.T) {
+	assert.False(t, ExitStatus{}.Failed())
+	assert.True(t, ExitStatus{Code: 1}.Failed())
+	assert.True(t, ExitStatus{Code: 130, Signal: syscall.SIGINT}.Failed())
+}
+
+func TestParseExitStatusSet(t *testing.T) {
+	set, err := parseExitStatusSet("1, 10-20,SIGKILL,term")
+	assert.Nil(t, err)
+	assert.Equal(t, []ExitCodeRange{{From: 1, To: 1}, {From: 10, To: 20}}, set.Codes)
+	assert.Equal(t, []syscall.Signal{syscall.SIGKILL, syscall.SIGTERM}, set.Signals)
+}
+
+func TestParseIncorrectExitStatusSet(t *testing.T) {
+	for _, definition := range []string{"", "1,", "5-3", "1-256", "-1", "1-x", "SIGNOPE"} {
+		_, err := parseExitStatusSet(definition)
+		assert.NotNil(t, err, definition)
+	}
+}
+
+func TestExitStatusSetMatches(t *testing.T) {
+	set := ExitStatusSet{
+		Codes:   []ExitCodeRange{{From: 0, To: 0}, {From: 3, To: 5}},
+		Signals: []syscall.Signal{syscall.SIGKILL},
+	}
+
+	assert.True(t, set.Matches(ExitStatus{Code: 0}))
+	assert.True(t, set.Matches(ExitStatus{Code: 4}))
+	assert.False(t, set.Matches(ExitStatus{Code: 6}))
+	assert.True(t, set.Matches(ExitStatus{Code: 130, Signal: syscall.SIGKILL}))
+	assert.False(t, set.Matches(ExitStatus{Code: 0, Signal: syscall.SIGTERM}))
+}
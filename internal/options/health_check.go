@@ -0,0 +1,146 @@
+// Package options defines common options set for the guide-dog app.
+package options
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Default* consts family defines default parameters of health checks.
+const (
+	DefaultHealthCheckInterval = 30 * time.Second
+	DefaultHealthCheckTimeout  = 10 * time.Second
+	DefaultHealthCheckRetries  = 3
+)
+
+// HealthCheckType defines the kind of probe health check does. Please
+// check HealthCheckType* constants family for the possible values.
+type HealthCheckType uint8
+
+// HealthCheckType* consts family defines possible probes: shell command
+// which has to exit with zero code, HTTP GET which has to respond with
+// 2xx or 3xx status and TCP connect.
+const (
+	HealthCheckTypeCommand HealthCheckType = iota
+	HealthCheckTypeHTTP
+	HealthCheckTypeTCP
+)
+
+func (hct HealthCheckType) String() string {
+	switch hct {
+	case HealthCheckTypeCommand:
+		return "cmd"
+	case HealthCheckTypeHTTP:
+		return "http"
+	case HealthCheckTypeTCP:
+		return "tcp"
+	default:
+		return "ERROR"
+	}
+}
+
+func parseHealthCheckType(name string) (checkType HealthCheckType, err error) {
+	switch strings.ToLower(name) {
+	case "cmd":
+		checkType = HealthCheckTypeCommand
+	case "http", "https":
+		checkType = HealthCheckTypeHTTP
+	case "tcp":
+		checkType = HealthCheckTypeTCP
+	default:
+		err = fmt.Errorf("Unknown health check type %s", name)
+	}
+
+	return
+}
+
+// HealthCheck defines a liveness probe of running command. Target is a
+// shell command, URL or host:port address according to Type. Probe is
+// done every Interval and fails if it is not finished within Timeout.
+// After Retries consecutive failures command is restarted. Failures
+// within StartPeriod after the start of command are not counted.
+type HealthCheck struct {
+	Type        HealthCheckType
+	Target      string
+	Interval    time.Duration
+	Timeout     time.Duration
+	Retries     int
+	StartPeriod time.Duration
+}
+
+func (hc HealthCheck) String() string {
+	if hc.Type == HealthCheckTypeHTTP {
+		return hc.Target
+	}
+
+	return hc.Type.String() + ConfigSourceSeparator + hc.Target
+}
+
+// parseHealthCheck parses health check definitions like "cmd:pg_isready",
+// "http://127.0.0.1:8080/health" or "tcp:127.0.0.1:5432".
+func parseHealthCheck(definition string) (check HealthCheck, err error) {
+	split := strings.SplitN(definition, ConfigSourceSeparator, 2)
+	if len(split) != 2 || split[1] == "" {
+		return check, fmt.Errorf("Incorrect health check %s", definition)
+	}
+
+	if check.Type, err = parseHealthCheckType(split[0]); err != nil {
+		return
+	}
+	check.Target = split[1]
+
+	switch check.Type {
+	case HealthCheckTypeHTTP:
+		check.Target = definition
+		parsed, err := url.Parse(definition)
+		if err != nil || parsed.Host == "" {
+			return check, fmt.Errorf("Incorrect URL of health check %s", definition)
+		}
+	case HealthCheckTypeTCP:
+		if _, _, err := net.SplitHostPort(check.Target); err != nil {
+			return check, fmt.Errorf("Incorrect address of health check %s", definition)
+		}
+	}
+
+	return
+}
+
+// WithHealthChecks sets health checks of supervised command. All checks
+// share the same interval, timeout, number of retries and start period.
+func WithHealthChecks(definitions []string,
+	interval time.Duration,
+	timeout time.Duration,
+	retries int,
+	startPeriod time.Duration) Option {
+	return func(options *Options) error {
+		switch {
+		case interval <= 0:
+			return fmt.Errorf("Health check interval has to be positive")
+		case timeout <= 0:
+			return fmt.Errorf("Health check timeout has to be positive")
+		case retries < 1:
+			return fmt.Errorf("Health check retries have to be positive")
+		case startPeriod < 0:
+			return fmt.Errorf("Health check start period cannot be negative")
+		}
+
+		checks := make([]HealthCheck, 0, len(definitions))
+		for _, definition := range definitions {
+			check, err := parseHealthCheck(definition)
+			if err != nil {
+				return err
+			}
+			check.Interval = interval
+			check.Timeout = timeout
+			check.Retries = retries
+			check.StartPeriod = startPeriod
+			checks = append(checks, check)
+		}
+		options.HealthChecks = checks
+
+		return nil
+	}
+}
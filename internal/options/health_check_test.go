@@ -0,0 +1,68 @@
+package options
+
+import (
+	"testing"
+	"time"
+
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestHealthCheckTypeString(t *testing.T) {
+	assert.Equal(t, HealthCheckTypeCommand.String(), "cmd")
+	assert.Equal(t, HealthCheckTypeHTTP.String(), "http")
+	assert.Equal(t, HealthCheckTypeTCP.String(), "tcp")
+	assert.Equal(t, HealthCheckType(0xFF).String(), "ERROR")
+}
+
+func TestParseHealthCheck(t *testing.T) {
+	check, err := parseHealthCheck("cmd:pg_isready -h localhost")
+	assert.Nil(t, err)
+	assert.Equal(t, check.Type, HealthCheckTypeCommand)
+	assert.Equal(t, check.Target, "pg_isready -h localhost")
+	assert.Equal(t, check.String(), "cmd:pg_isready -h localhost")
+
+	check, err = parseHealthCheck("http://127.0.0.1:8080/health")
+	assert.Nil(t, err)
+	assert.Equal(t, check.Type, HealthCheckTypeHTTP)
+	assert.Equal(t, check.Target, "http://127.0.0.1:8080/health")
+	assert.Equal(t, check.String(), "http://127.0.0.1:8080/health")
+
+	check, err = parseHealthCheck("TCP:127.0.0.1:5432")
+	assert.Nil(t, err)
+	assert.Equal(t, check.Type, HealthCheckTypeTCP)
+	assert.Equal(t, check.Target, "127.0.0.1:5432")
+}
+
+func TestParseIncorrectHealthCheck(t *testing.T) {
+	for _, definition := range []string{
+		"pg_isready",
+		"cmd:",
+		"udp:127.0.0.1:53",
+		"http:/health",
+		"tcp:127.0.0.1",
+	} {
+		_, err := parseHealthCheck(definition)
+		assert.NotNil(t, err, definition)
+	}
+}
+
+func TestWithHealthChecks(t *testing.T) {
+	options := &Options{}
+	err := WithHealthChecks([]string{"cmd:true", "tcp:localhost:80"},
+		time.Second, 2*time.Second, 3, time.Minute)(options)
+	assert.Nil(t, err)
+	assert.Equal(t, len(options.HealthChecks), 2)
+	assert.Equal(t, options.HealthChecks[1], HealthCheck{
+		Type:        HealthCheckTypeTCP,
+		Target:      "localhost:80",
+		Interval:    time.Second,
+		Timeout:     2 * time.Second,
+		Retries:     3,
+		StartPeriod: time.Minute,
+	})
+
+	assert.NotNil(t, WithHealthChecks([]string{}, 0, time.Second, 3, 0)(options))
+	assert.NotNil(t, WithHealthChecks([]string{}, time.Second, 0, 3, 0)(options))
+	assert.NotNil(t, WithHealthChecks([]string{}, time.Second, time.Second, 0, 0)(options))
+	assert.NotNil(t, WithHealthChecks([]string{"ping"}, time.Second, time.Second, 3, 0)(options))
+}
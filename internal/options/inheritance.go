@@ -0,0 +1,57 @@
+// Package options defines common options set for the guide-dog app.
+package options
+
+import "path/filepath"
+
+// Inheritance defines which environment variables of guide-dog itself are
+// inherited by the executed command. If Clean is set, nothing is inherited.
+// Otherwise variable is inherited if it matches any of Allow glob patterns
+// (or Allow is empty) and does not match any of Deny glob patterns.
+type Inheritance struct {
+	Clean bool
+	Allow []string
+	Deny  []string
+}
+
+// Inherits tells if variable with the given name has to be inherited.
+func (inh Inheritance) Inherits(name string) bool {
+	if inh.Clean {
+		return false
+	}
+
+	if len(inh.Allow) > 0 && !matchesAny(inh.Allow, name) {
+		return false
+	}
+
+	return !matchesAny(inh.Deny, name)
+}
+
+// matchesAny checks if name matches any of given glob patterns.
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WithInheritance sets the rules of inheritance of environment variables.
+func WithInheritance(clean bool, allow []string, deny []string) Option {
+	return func(options *Options) error {
+		for _, pattern := range append(allow[:len(allow):len(allow)], deny...) {
+			if _, err := filepath.Match(pattern, ""); err != nil {
+				return err
+			}
+		}
+
+		options.Inheritance = Inheritance{
+			Clean: clean,
+			Allow: allow,
+			Deny:  deny,
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,75 @@
+package options
+
+import (
+	"testing"
+
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestInheritsEverythingByDefault(t *testing.T) {
+	inheritance := Inheritance{}
+
+	assert.True(t, inheritance.Inherits("PATH"))
+	assert.True(t, inheritance.Inherits("AWS_SECRET_ACCESS_KEY"))
+}
+
+func TestInheritsNothingIfClean(t *testing.T) {
+	inheritance := Inheritance{Clean: true, Allow: []string{"PATH"}}
+
+	assert.False(t, inheritance.Inherits("PATH"))
+	assert.False(t, inheritance.Inherits("HOME"))
+}
+
+func TestInheritsAllowDeny(t *testing.T) {
+	inheritance := Inheritance{
+		Allow: []string{"PATH", "HOME", "AWS_*"},
+		Deny:  []string{"AWS_SECRET*"},
+	}
+
+	assert.True(t, inheritance.Inherits("PATH"))
+	assert.True(t, inheritance.Inherits("HOME"))
+	assert.True(t, inheritance.Inherits("AWS_REGION"))
+	assert.False(t, inheritance.Inherits("AWS_SECRET_ACCESS_KEY"))
+	assert.False(t, inheritance.Inherits("SHELL"))
+
+	inheritance = Inheritance{Deny: []string{"AWS_*"}}
+
+	assert.True(t, inheritance.Inherits("PATH"))
+	assert.False(t, inheritance.Inherits("AWS_REGION"))
+}
+
+func TestWithInheritance(t *testing.T) {
+	options, err := NewOptions("term", // signal
+		[]string{}, // envs
+		0,          // gracefulTimeout
+		"json",     // configFormat
+		[]string{}, // configPaths
+		[]string{}, // pathsToTracks
+		"",         // lockFile
+		false,      // pty
+		false,      // supervise
+		false,      // restartOnConfigChanges
+		[]string{}, // exitCodes
+		WithInheritance(false, []string{"PATH"}, []string{"AWS_*"}))
+
+	assert.Nil(t, err)
+	assert.Equal(t, options.Inheritance.Allow, []string{"PATH"})
+	assert.Equal(t, options.Inheritance.Deny, []string{"AWS_*"})
+}
+
+func TestWithIncorrectInheritancePattern(t *testing.T) {
+	_, err := NewOptions("term", // signal
+		[]string{}, // envs
+		0,          // gracefulTimeout
+		"json",     // configFormat
+		[]string{}, // configPaths
+		[]string{}, // pathsToTracks
+		"",         // lockFile
+		false,      // pty
+		false,      // supervise
+		false,      // restartOnConfigChanges
+		[]string{}, // exitCodes
+		WithInheritance(false, []string{}, []string{"AWS_["}))
+
+	assert.NotNil(t, err)
+}
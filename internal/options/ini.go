@@ -0,0 +1,78 @@
+// Package options defines common options set for the guide-dog app.
+package options
+
+import (
+	"fmt"
+	"strings"
+)
+
+// INIMode defines how sections of INI configs are treated. Please check
+// INIMode* constants family for the possible values.
+type INIMode uint8
+
+// INIMode* consts family defines possible ways to handle INI sections.
+const (
+	INIModeMerge INIMode = iota
+	INIModePrefix
+	INIModeSelect
+)
+
+func (im INIMode) String() string {
+	switch im {
+	case INIModeMerge:
+		return "merge"
+	case INIModePrefix:
+		return "prefix"
+	case INIModeSelect:
+		return "select"
+	default:
+		return "ERROR"
+	}
+}
+
+func parseINIMode(name string) (mode INIMode, err error) {
+	switch strings.ToLower(name) {
+	case "merge":
+		mode = INIModeMerge
+	case "prefix":
+		mode = INIModePrefix
+	case "select":
+		mode = INIModeSelect
+	default:
+		err = fmt.Errorf("Unknown INI mode %s", name)
+	}
+
+	return
+}
+
+// INI defines how to handle sections of INI configs. Sections are
+// meaningful only for INIModeSelect: they are loaded in the given order on
+// top of the global section.
+type INI struct {
+	Mode     INIMode
+	Sections []string
+}
+
+// WithINI sets the way sections of INI configs are handled.
+func WithINI(mode string, sections []string) Option {
+	return func(options *Options) error {
+		convertedMode, err := parseINIMode(mode)
+		if err != nil {
+			return err
+		}
+
+		if len(sections) > 0 && convertedMode != INIModeSelect {
+			return fmt.Errorf("INI sections may be set only in %s mode", INIModeSelect)
+		}
+		if len(sections) == 0 && convertedMode == INIModeSelect {
+			return fmt.Errorf("INI sections are required in %s mode", INIModeSelect)
+		}
+
+		options.INI = INI{
+			Mode:     convertedMode,
+			Sections: sections,
+		}
+
+		return nil
+	}
+}
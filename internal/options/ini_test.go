@@ -0,0 +1,83 @@
+package options
+
+import (
+	"strings"
+	"testing"
+
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestParseINIMode(t *testing.T) {
+	validNames := []string{"merge", "prefix", "select"}
+	modes := []INIMode{INIModeMerge, INIModePrefix, INIModeSelect}
+
+	for idx, name := range validNames {
+		for _, caseSensitiveName := range []string{name, strings.ToUpper(name)} {
+			mode, err := parseINIMode(caseSensitiveName)
+			assert.Nil(t, err)
+			assert.Equal(t, modes[idx], mode)
+		}
+	}
+
+	_, err := parseINIMode("WTF")
+	assert.NotNil(t, err)
+}
+
+func TestINIModeNames(t *testing.T) {
+	assert.Equal(t, INIModeMerge.String(), "merge")
+	assert.Equal(t, INIModePrefix.String(), "prefix")
+	assert.Equal(t, INIModeSelect.String(), "select")
+}
+
+func TestWithINI(t *testing.T) {
+	options, err := NewOptions("term", // signal
+		[]string{}, // envs
+		0,          // gracefulTimeout
+		"ini",      // configFormat
+		[]string{}, // configPaths
+		[]string{}, // pathsToTracks
+		"",         // lockFile
+		false,      // pty
+		false,      // supervise
+		false,      // restartOnConfigChanges
+		[]string{}, // exitCodes
+		WithINI("select", []string{"staging", "local"}))
+
+	assert.Nil(t, err)
+	assert.Equal(t, options.INI.Mode, INIModeSelect)
+	assert.Equal(t, options.INI.Sections, []string{"staging", "local"})
+}
+
+func TestWithINISectionsInWrongMode(t *testing.T) {
+	_, err := NewOptions("term", // signal
+		[]string{}, // envs
+		0,          // gracefulTimeout
+		"ini",      // configFormat
+		[]string{}, // configPaths
+		[]string{}, // pathsToTracks
+		"",         // lockFile
+		false,      // pty
+		false,      // supervise
+		false,      // restartOnConfigChanges
+		[]string{}, // exitCodes
+		WithINI("prefix", []string{"staging"}))
+
+	assert.NotNil(t, err)
+}
+
+func TestWithINISelectWithoutSections(t *testing.T) {
+	_, err := NewOptions("term", // signal
+		[]string{}, // envs
+		0,          // gracefulTimeout
+		"ini",      // configFormat
+		[]string{}, // configPaths
+		[]string{}, // pathsToTracks
+		"",         // lockFile
+		false,      // pty
+		false,      // supervise
+		false,      // restartOnConfigChanges
+		[]string{}, // exitCodes
+		WithINI("select", []string{}))
+
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,98 @@
+// Package options defines common options set for the guide-dog app.
+package options
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultNameSeparator is a separator which is used to compose names of
+// the environment variables from nested structures by default.
+const DefaultNameSeparator = "_"
+
+// NameCase defines case transformation of composed names of environment
+// variables. Please check NameCase* constants family for the possible
+// values.
+type NameCase uint8
+
+// NameCase* consts family defines possible case transformations, supported
+// by the guide-dog.
+const (
+	NameCaseKeep NameCase = iota
+	NameCaseUpper
+	NameCaseLower
+)
+
+func (nc NameCase) String() string {
+	switch nc {
+	case NameCaseKeep:
+		return "keep"
+	case NameCaseUpper:
+		return "upper"
+	case NameCaseLower:
+		return "lower"
+	default:
+		return "ERROR"
+	}
+}
+
+// Apply transforms the case of the given name.
+func (nc NameCase) Apply(name string) string {
+	switch nc {
+	case NameCaseUpper:
+		return strings.ToUpper(name)
+	case NameCaseLower:
+		return strings.ToLower(name)
+	default:
+		return name
+	}
+}
+
+func parseNameCase(name string) (nameCase NameCase, err error) {
+	switch strings.ToLower(name) {
+	case "keep":
+		nameCase = NameCaseKeep
+	case "upper":
+		nameCase = NameCaseUpper
+	case "lower":
+		nameCase = NameCaseLower
+	default:
+		err = fmt.Errorf("Unknown name case %s", name)
+	}
+
+	return
+}
+
+// Naming defines how to compose names of environment variables from
+// nested structures like {"database": {"host": ...}}.
+type Naming struct {
+	Separator string
+	Case      NameCase
+}
+
+// Compose builds the name of the environment variable from the path of
+// keys. Top level names are returned as is.
+func (n Naming) Compose(path []string) string {
+	if len(path) == 1 {
+		return path[0]
+	}
+
+	return n.Case.Apply(strings.Join(path, n.Separator))
+}
+
+// WithNaming sets the way names of nested values are composed.
+func WithNaming(separator string, nameCase string) Option {
+	return func(options *Options) error {
+		convertedNameCase, err := parseNameCase(nameCase)
+		if err != nil {
+			return err
+		}
+
+		options.Naming = Naming{
+			Separator: separator,
+			Case:      convertedNameCase,
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,78 @@
+package options
+
+import (
+	"strings"
+	"testing"
+
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestParseNameCase(t *testing.T) {
+	validNames := []string{"keep", "upper", "lower"}
+	cases := []NameCase{NameCaseKeep, NameCaseUpper, NameCaseLower}
+
+	for idx, name := range validNames {
+		for _, caseSensitiveName := range []string{name, strings.ToUpper(name)} {
+			nameCase, err := parseNameCase(caseSensitiveName)
+			assert.Nil(t, err)
+			assert.Equal(t, cases[idx], nameCase)
+		}
+	}
+}
+
+func TestParseUnknownNameCase(t *testing.T) {
+	_, err := parseNameCase("WTF")
+	assert.NotNil(t, err)
+}
+
+func TestNameCaseNames(t *testing.T) {
+	assert.Equal(t, NameCaseKeep.String(), "keep")
+	assert.Equal(t, NameCaseUpper.String(), "upper")
+	assert.Equal(t, NameCaseLower.String(), "lower")
+}
+
+func TestNamingCompose(t *testing.T) {
+	naming := Naming{Separator: "_", Case: NameCaseUpper}
+
+	assert.Equal(t, naming.Compose([]string{"host"}), "host")
+	assert.Equal(t, naming.Compose([]string{"database", "host"}), "DATABASE_HOST")
+
+	naming = Naming{Separator: "__", Case: NameCaseKeep}
+	assert.Equal(t, naming.Compose([]string{"Database", "Host"}), "Database__Host")
+}
+
+func TestWithNaming(t *testing.T) {
+	options, err := NewOptions("term", // signal
+		[]string{}, // envs
+		0,          // gracefulTimeout
+		"json",     // configFormat
+		[]string{}, // configPaths
+		[]string{}, // pathsToTracks
+		"",         // lockFile
+		false,      // pty
+		false,      // supervise
+		false,      // restartOnConfigChanges
+		[]string{}, // exitCodes
+		WithNaming(".", "lower"))
+
+	assert.Nil(t, err)
+	assert.Equal(t, options.Naming.Separator, ".")
+	assert.Equal(t, options.Naming.Case, NameCaseLower)
+}
+
+func TestWithIncorrectNaming(t *testing.T) {
+	_, err := NewOptions("term", // signal
+		[]string{}, // envs
+		0,          // gracefulTimeout
+		"json",     // configFormat
+		[]string{}, // configPaths
+		[]string{}, // pathsToTracks
+		"",         // lockFile
+		false,      // pty
+		false,      // supervise
+		false,      // restartOnConfigChanges
+		[]string{}, // exitCodes
+		WithNaming(".", "WTF"))
+
+	assert.NotNil(t, err)
+}
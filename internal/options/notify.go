@@ -0,0 +1,36 @@
+// Package options defines common options set for the guide-dog app.
+package options
+
+import (
+	"fmt"
+	"time"
+)
+
+// Notify defines sd_notify protocol support for the command. If Enabled
+// is set, command gets its own NOTIFY_SOCKET and readiness is reported to
+// systemd only after command sends READY=1. If WatchdogTimeout is set,
+// command has to send WATCHDOG=1 within this timeout, otherwise it is
+// restarted. Zero WatchdogTimeout disables watchdog.
+type Notify struct {
+	Enabled         bool
+	WatchdogTimeout time.Duration
+}
+
+// WithNotify sets sd_notify protocol support for the command.
+func WithNotify(enabled bool, watchdogTimeout time.Duration) Option {
+	return func(options *Options) error {
+		switch {
+		case watchdogTimeout < 0:
+			return fmt.Errorf("Watchdog timeout cannot be negative")
+		case watchdogTimeout > 0 && !enabled:
+			return fmt.Errorf("Watchdog requires notify socket to be enabled")
+		}
+
+		options.Notify = Notify{
+			Enabled:         enabled,
+			WatchdogTimeout: watchdogTimeout,
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,20 @@
+package options
+
+import (
+	"testing"
+	"time"
+
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestWithNotify(t *testing.T) {
+	options := &Options{}
+	assert.Nil(t, WithNotify(true, 10*time.Second)(options))
+	assert.Equal(t, options.Notify, Notify{Enabled: true, WatchdogTimeout: 10 * time.Second})
+
+	assert.Nil(t, WithNotify(false, 0)(options))
+	assert.Equal(t, options.Notify, Notify{})
+
+	assert.NotNil(t, WithNotify(true, -time.Second)(options))
+	assert.NotNil(t, WithNotify(false, time.Second)(options))
+}
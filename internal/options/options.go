@@ -14,35 +14,66 @@ import (
 
 // Options is just a storage of the possible options with some interpretations.
 type Options struct {
-	ConfigFormat    ConfigFormat
-	ConfigPath      string
+	ConfigSources   []ConfigSource
+	DecryptionKey   string
+	EnvDir          EnvDir
 	Envs            map[string]string
+	ExecInterval    time.Duration
 	ExitCodes       map[int]bool
 	GracefulTimeout time.Duration
+	HealthChecks    []HealthCheck
+	INI             INI
+	Inheritance     Inheritance
+	Interpolate     bool
 	LockFile        *lockfile.Lock
+	Naming          Naming
+	Notify          Notify
 	PathsToTrack    []string
+	Print           Print
+	Properties      Properties
 	PTY             bool
+	Reload          Reload
+	Restart         Restart
+	RestartPolicy   RestartPolicy
+	Schema          Schema
+	SecretFiles     SecretFiles
 	Signal          syscall.Signal
 	Supervisor      SupervisorMode
+	Values          ValueConversion
 }
 
+// Option tunes Options which are built by NewOptions. Please check With*
+// functions family for the possible options.
+type Option func(*Options) error
+
 func (opt *Options) String() string {
 	return fmt.Sprintf("%+v", *opt)
 }
 
-// NewOptions builds new Options struct based on the given parameter list
+// WithInterpolation enables expansion of ${VAR} and ${VAR:-default}
+// references in config values.
+func WithInterpolation(enabled bool) Option {
+	return func(options *Options) error {
+		options.Interpolate = enabled
+		return nil
+	}
+}
+
+// NewOptions builds new Options struct based on the given parameter list.
+// Additional options are applied in the given order.
 func NewOptions(signal string,
 	envs []string,
 	gracefulTimeout time.Duration,
 	configFormat string,
-	configPath string,
+	configPaths []string,
 	pathsToTrack []string,
 	lockFile string,
 	pty bool,
 	supervise bool,
 	restartOnConfigChanges bool,
-	exitOnCodes []string) (options *Options, err error) {
-	convertedConfigFormat, err := parseConfigFormat(configFormat)
+	exitOnCodes []string,
+	extraOptions ...Option) (options *Options, err error) {
+	_, err = parseConfigFormat(configFormat)
 	if err != nil {
 		log.WithFields(log.Fields{
 			"configFormat": configFormat,
@@ -51,6 +82,19 @@ func NewOptions(signal string,
 		return
 	}
 
+	configSources := make([]ConfigSource, 0, len(configPaths))
+	for _, path := range configPaths {
+		source, err := parseConfigSource(path, configFormat)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"configPath": path,
+				"error":      err,
+			}).Errorf("Cannot convert configPath.")
+			return nil, err
+		}
+		configSources = append(configSources, source)
+	}
+
 	convertedSignal, err := parseSignalName(signal)
 	if err != nil {
 		log.WithFields(log.Fields{
@@ -62,14 +106,6 @@ func NewOptions(signal string,
 
 	convertedEnvs := parseEnvs(envs)
 
-	supervisorMode := SupervisorModeNone
-	if supervise {
-		supervisorMode |= SupervisorModeSimple
-	}
-	if restartOnConfigChanges {
-		supervisorMode |= SupervisorModeRestarting
-	}
-
 	var convertedLockFile *lockfile.Lock
 	if lockFile != "" {
 		convertedLockFile = lockfile.NewLock(lockFile)
@@ -84,17 +120,57 @@ func NewOptions(signal string,
 		exitCodes[intValue] = true
 	}
 
+	// Supervising restarts command unless it exits with one of exitCodes.
+	supervisorMode := SupervisorModeNone
+	restartPolicy := RestartPolicy{}
+	if supervise {
+		supervisorMode = SupervisorModeAlways
+		if len(exitCodes) > 0 {
+			supervisorMode = SupervisorModeUnlessExitCode
+			for code := range exitCodes {
+				restartPolicy.ExitStatuses.Codes = append(restartPolicy.ExitStatuses.Codes,
+					ExitCodeRange{From: code, To: code})
+			}
+		}
+	}
+	if restartOnConfigChanges {
+		supervisorMode |= SupervisorModeRestarting
+	}
+
 	options = &Options{
-		ConfigFormat:    convertedConfigFormat,
-		ConfigPath:      configPath,
+		ConfigSources:   configSources,
 		Envs:            convertedEnvs,
 		ExitCodes:       exitCodes,
 		GracefulTimeout: gracefulTimeout,
 		LockFile:        convertedLockFile,
-		PathsToTrack:    pathsToTrack,
-		PTY:             pty,
-		Signal:          convertedSignal,
-		Supervisor:      supervisorMode,
+		Naming: Naming{
+			Separator: DefaultNameSeparator,
+			Case:      NameCaseUpper,
+		},
+		PathsToTrack: pathsToTrack,
+		PTY:          pty,
+		Restart: Restart{
+			Delay:              DefaultRestartDelay,
+			MaxDelay:           DefaultRestartMaxDelay,
+			Jitter:             float64(DefaultRestartJitter) / 100,
+			StartTime:          DefaultStartTime,
+			FailedStartsWindow: DefaultFailedStartsWindow,
+		},
+		RestartPolicy: restartPolicy,
+		Signal:        convertedSignal,
+		Supervisor:    supervisorMode,
+		Values: ValueConversion{
+			Null:           NullModeUnset,
+			Array:          ArrayModeJoin,
+			ArraySeparator: DefaultArraySeparator,
+		},
+	}
+
+	for _, option := range extraOptions {
+		if err = option(options); err != nil {
+			log.WithField("error", err).Errorf("Cannot apply option.")
+			return nil, err
+		}
 	}
 
 	return
@@ -11,7 +11,7 @@ func TestStringer(t *testing.T) {
 		[]string{}, // envs
 		0,          // gracefulTimeout
 		"json",     // configFormat
-		"",         // configPath
+		[]string{}, // configPaths
 		[]string{}, // pathsToTracks
 		"",         // lockFile
 		false,      // pty
@@ -27,7 +27,7 @@ func TestIncorrectConfigFormat(t *testing.T) {
 		[]string{}, // envs
 		0,          // gracefulTimeout
 		"WTF",      // configFormat
-		"",         // configPath
+		[]string{}, // configPaths
 		[]string{}, // pathsToTracks
 		"",         // lockFile
 		false,      // pty
@@ -43,7 +43,7 @@ func TestIncorrectSignalName(t *testing.T) {
 		[]string{}, // envs
 		0,          // gracefulTimeout
 		"json",     // configFormat
-		"",         // configPath
+		[]string{}, // configPaths
 		[]string{}, // pathsToTracks
 		"",         // lockFile
 		false,      // pty
@@ -59,7 +59,7 @@ func TestIncorrectExitCodes(t *testing.T) {
 		[]string{},      // envs
 		0,               // gracefulTimeout
 		"json",          // configFormat
-		"",              // configPath
+		[]string{},      // configPaths
 		[]string{},      // pathsToTracks
 		"",              // lockFile
 		false,           // pty
@@ -75,7 +75,7 @@ func TestCorrectExitCodes(t *testing.T) {
 		[]string{}, // envs
 		0,          // gracefulTimeout
 		"json",     // configFormat
-		"",         // configPath
+		[]string{}, // configPaths
 		[]string{}, // pathsToTracks
 		"",         // lockFile
 		false,      // pty
@@ -88,3 +88,22 @@ func TestCorrectExitCodes(t *testing.T) {
 	assert.True(t, options.ExitCodes[1])
 	assert.True(t, options.ExitCodes[2])
 }
+
+func TestSuperviseWithExitCodes(t *testing.T) {
+	options, err := NewOptions("term", // signal
+		[]string{},    // envs
+		0,             // gracefulTimeout
+		"json",        // configFormat
+		[]string{},    // configPaths
+		[]string{},    // pathsToTracks
+		"",            // lockFile
+		false,         // pty
+		true,          // supervise
+		true,          // restartOnConfigChanges
+		[]string{"3"}) // exitCodes
+
+	assert.Nil(t, err)
+	assert.Equal(t, options.Supervisor, SupervisorModeUnlessExitCode|SupervisorModeRestarting)
+	assert.True(t, options.RestartPolicy.ExitStatuses.Matches(ExitStatus{Code: 3}))
+	assert.False(t, options.RestartPolicy.ExitStatuses.Matches(ExitStatus{Code: 0}))
+}
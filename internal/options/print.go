@@ -0,0 +1,90 @@
+// Package options defines common options set for the guide-dog app.
+package options
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PrintFormat defines the format resolved environment is printed in by
+// print-env option. Please check PrintFormat* constants family for the
+// possible values.
+type PrintFormat uint8
+
+// PrintFormat* consts family defines possible formats of printed
+// environment: shell export lines, JSON object, Docker --env-file, systemd
+// EnvironmentFile and envdir directory.
+const (
+	PrintFormatExport PrintFormat = iota
+	PrintFormatJSON
+	PrintFormatDocker
+	PrintFormatSystemd
+	PrintFormatEnvDir
+)
+
+func (pf PrintFormat) String() string {
+	switch pf {
+	case PrintFormatExport:
+		return "export"
+	case PrintFormatJSON:
+		return "json"
+	case PrintFormatDocker:
+		return "docker"
+	case PrintFormatSystemd:
+		return "systemd"
+	case PrintFormatEnvDir:
+		return "envdir"
+	default:
+		return "ERROR"
+	}
+}
+
+func parsePrintFormat(name string) (format PrintFormat, err error) {
+	switch strings.ToLower(name) {
+	case "export":
+		format = PrintFormatExport
+	case "json":
+		format = PrintFormatJSON
+	case "docker":
+		format = PrintFormatDocker
+	case "systemd":
+		format = PrintFormatSystemd
+	case "envdir":
+		format = PrintFormatEnvDir
+	default:
+		err = fmt.Errorf("Unknown print format %s", name)
+	}
+
+	return
+}
+
+// Print defines how resolved environment is printed by print-env option.
+// Directory is the output directory for PrintFormatEnvDir. If Origins is
+// set, origins of the values are printed too.
+type Print struct {
+	Format    PrintFormat
+	Directory string
+	Origins   bool
+}
+
+// WithPrint sets the way resolved environment is printed.
+func WithPrint(format string, directory string, origins bool) Option {
+	return func(options *Options) error {
+		convertedFormat, err := parsePrintFormat(format)
+		if err != nil {
+			return err
+		}
+
+		if convertedFormat == PrintFormatEnvDir && directory == "" {
+			return fmt.Errorf("Output directory is required for envdir format")
+		}
+
+		options.Print = Print{
+			Format:    convertedFormat,
+			Directory: directory,
+			Origins:   origins,
+		}
+
+		return nil
+	}
+}
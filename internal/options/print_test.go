@@ -0,0 +1,35 @@
+package options
+
+import (
+	"testing"
+
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestPrintFormatString(t *testing.T) {
+	assert.Equal(t, PrintFormatExport.String(), "export")
+	assert.Equal(t, PrintFormatJSON.String(), "json")
+	assert.Equal(t, PrintFormatDocker.String(), "docker")
+	assert.Equal(t, PrintFormatSystemd.String(), "systemd")
+	assert.Equal(t, PrintFormatEnvDir.String(), "envdir")
+	assert.Equal(t, PrintFormat(0xFF).String(), "ERROR")
+}
+
+func TestParsePrintFormat(t *testing.T) {
+	format, err := parsePrintFormat("Docker")
+	assert.Nil(t, err)
+	assert.Equal(t, format, PrintFormatDocker)
+
+	_, err = parsePrintFormat("xml")
+	assert.NotNil(t, err)
+}
+
+func TestWithPrint(t *testing.T) {
+	options := &Options{}
+	assert.Nil(t, WithPrint("systemd", "", true)(options))
+	assert.Equal(t, options.Print, Print{Format: PrintFormatSystemd, Origins: true})
+
+	assert.NotNil(t, WithPrint("envdir", "", false)(options))
+	assert.Nil(t, WithPrint("envdir", "/tmp/env", false)(options))
+	assert.Equal(t, options.Print.Directory, "/tmp/env")
+}
@@ -0,0 +1,22 @@
+// Package options defines common options set for the guide-dog app.
+package options
+
+// PropertiesKeySeparator separates parts of keys in Java properties
+// files (e.g. db.host).
+const PropertiesKeySeparator = "."
+
+// Properties defines how Java properties files are read. If ConvertNames
+// is set, keys like db.host are converted into names according to Naming
+// (DB_HOST by default).
+type Properties struct {
+	ConvertNames bool
+}
+
+// WithProperties sets the way Java properties files are read.
+func WithProperties(convertNames bool) Option {
+	return func(options *Options) error {
+		options.Properties = Properties{ConvertNames: convertNames}
+
+		return nil
+	}
+}
@@ -0,0 +1,23 @@
+// Package options defines common options set for the guide-dog app.
+package options
+
+// Reload defines what to do if environment cannot be reloaded. If Strict
+// is set, failed reload keeps the current environment and does not
+// restart the command. FailureHook is a shell command which is run on
+// every failed reload.
+type Reload struct {
+	Strict      bool
+	FailureHook string
+}
+
+// WithReload sets the policy of environment reloads.
+func WithReload(strict bool, failureHook string) Option {
+	return func(options *Options) error {
+		options.Reload = Reload{
+			Strict:      strict,
+			FailureHook: failureHook,
+		}
+
+		return nil
+	}
+}
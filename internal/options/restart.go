@@ -0,0 +1,83 @@
+// Package options defines common options set for the guide-dog app.
+package options
+
+import (
+	"fmt"
+	"time"
+)
+
+// Default* consts family defines default policy of restarts.
+const (
+	DefaultRestartDelay       = time.Second
+	DefaultRestartMaxDelay    = time.Minute
+	DefaultRestartJitter      = 10
+	DefaultStartTime          = time.Second
+	DefaultFailedStartsWindow = 5 * time.Minute
+)
+
+// Restart defines the policy of restarts of supervised command. A run
+// shorter than StartTime is a failed start. After failed start command is
+// restarted with exponential backoff: Delay is doubled for each
+// consecutive failed start up to MaxDelay and randomized by Jitter
+// (fraction of the delay). Successful start resets the backoff. If there
+// are MaxFailedStarts failed starts within FailedStartsWindow, supervising
+// is stopped. Zero MaxFailedStarts means no limit.
+type Restart struct {
+	Delay              time.Duration
+	MaxDelay           time.Duration
+	Jitter             float64
+	StartTime          time.Duration
+	MaxFailedStarts    int
+	FailedStartsWindow time.Duration
+}
+
+// Backoff returns the delay before restart after the given number of
+// consecutive failed starts. Jitter is not applied.
+func (r Restart) Backoff(failedStarts int) time.Duration {
+	if failedStarts <= 0 {
+		return 0
+	}
+
+	delay := r.Delay
+	for idx := 1; idx < failedStarts && delay < r.MaxDelay; idx++ {
+		delay *= 2
+	}
+	if delay > r.MaxDelay {
+		delay = r.MaxDelay
+	}
+
+	return delay
+}
+
+// WithRestart sets the policy of restarts. Jitter is set in percents of
+// the delay.
+func WithRestart(delay time.Duration,
+	maxDelay time.Duration,
+	jitterPercent int,
+	startTime time.Duration,
+	maxFailedStarts int,
+	failedStartsWindow time.Duration) Option {
+	return func(options *Options) error {
+		switch {
+		case delay < 0 || maxDelay < 0 || startTime < 0 || failedStartsWindow < 0:
+			return fmt.Errorf("Restart durations cannot be negative")
+		case maxDelay < delay:
+			return fmt.Errorf("Maximal restart delay %s is less than restart delay %s", maxDelay, delay)
+		case jitterPercent < 0 || jitterPercent > 100:
+			return fmt.Errorf("Restart jitter has to be in 0..100 percents, got %d", jitterPercent)
+		case maxFailedStarts < 0:
+			return fmt.Errorf("Maximal number of failed starts cannot be negative")
+		}
+
+		options.Restart = Restart{
+			Delay:              delay,
+			MaxDelay:           maxDelay,
+			Jitter:             float64(jitterPercent) / 100,
+			StartTime:          startTime,
+			MaxFailedStarts:    maxFailedStarts,
+			FailedStartsWindow: failedStartsWindow,
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,37 @@
+package options
+
+import (
+	"testing"
+	"time"
+
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestRestartBackoff(t *testing.T) {
+	restart := Restart{Delay: time.Second, MaxDelay: 10 * time.Second}
+
+	assert.Equal(t, restart.Backoff(0), time.Duration(0))
+	assert.Equal(t, restart.Backoff(1), time.Second)
+	assert.Equal(t, restart.Backoff(2), 2*time.Second)
+	assert.Equal(t, restart.Backoff(4), 8*time.Second)
+	assert.Equal(t, restart.Backoff(5), 10*time.Second)
+	assert.Equal(t, restart.Backoff(100), 10*time.Second)
+}
+
+func TestWithRestart(t *testing.T) {
+	options := &Options{}
+	assert.Nil(t, WithRestart(time.Second, time.Minute, 20, 2*time.Second, 5, time.Minute)(options))
+	assert.Equal(t, options.Restart, Restart{
+		Delay:              time.Second,
+		MaxDelay:           time.Minute,
+		Jitter:             0.2,
+		StartTime:          2 * time.Second,
+		MaxFailedStarts:    5,
+		FailedStartsWindow: time.Minute,
+	})
+
+	assert.NotNil(t, WithRestart(-time.Second, time.Minute, 0, 0, 0, 0)(options))
+	assert.NotNil(t, WithRestart(time.Minute, time.Second, 0, 0, 0, 0)(options))
+	assert.NotNil(t, WithRestart(time.Second, time.Minute, 101, 0, 0, 0)(options))
+	assert.NotNil(t, WithRestart(time.Second, time.Minute, 0, 0, -1, 0)(options))
+}
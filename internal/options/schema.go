@@ -0,0 +1,209 @@
+// Package options defines common options set for the guide-dog app.
+package options
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// VariableType defines the type of the value of environment variable.
+// Please check VariableType* constants family for the possible values.
+type VariableType uint8
+
+// VariableType* consts family defines possible types of environment
+// variables, supported by schema.
+const (
+	VariableTypeString VariableType = iota
+	VariableTypeInt
+	VariableTypeBool
+	VariableTypeURL
+	VariableTypeDuration
+)
+
+func (vt VariableType) String() string {
+	switch vt {
+	case VariableTypeString:
+		return "string"
+	case VariableTypeInt:
+		return "int"
+	case VariableTypeBool:
+		return "bool"
+	case VariableTypeURL:
+		return "url"
+	case VariableTypeDuration:
+		return "duration"
+	default:
+		return "ERROR"
+	}
+}
+
+// Check verifies that the value has the type.
+func (vt VariableType) Check(value string) (err error) {
+	switch vt {
+	case VariableTypeInt:
+		_, err = strconv.ParseInt(value, 10, 64)
+	case VariableTypeBool:
+		_, err = strconv.ParseBool(value)
+	case VariableTypeURL:
+		var parsed *url.URL
+		parsed, err = url.Parse(value)
+		if err == nil && (parsed.Scheme == "" || (parsed.Host == "" && parsed.Opaque == "")) {
+			err = fmt.Errorf("scheme or host is missing")
+		}
+	case VariableTypeDuration:
+		_, err = time.ParseDuration(value)
+	}
+
+	if err != nil {
+		err = fmt.Errorf("%q is not a valid %s", value, vt)
+	}
+
+	return
+}
+
+func parseVariableType(name string) (variableType VariableType, err error) {
+	switch strings.ToLower(name) {
+	case "", "string":
+		variableType = VariableTypeString
+	case "int":
+		variableType = VariableTypeInt
+	case "bool":
+		variableType = VariableTypeBool
+	case "url":
+		variableType = VariableTypeURL
+	case "duration":
+		variableType = VariableTypeDuration
+	default:
+		err = fmt.Errorf("Unknown variable type %s", name)
+	}
+
+	return
+}
+
+// SchemaVariable defines the rules for a single environment variable.
+type SchemaVariable struct {
+	Name       string
+	Required   bool
+	Type       VariableType
+	Pattern    *regexp.Regexp
+	Allowed    []string
+	Default    string
+	HasDefault bool
+}
+
+// Check verifies the value of the variable against the rules.
+func (sv SchemaVariable) Check(value string) error {
+	if err := sv.Type.Check(value); err != nil {
+		return err
+	}
+
+	if sv.Pattern != nil && !sv.Pattern.MatchString(value) {
+		return fmt.Errorf("%q does not match %s", value, sv.Pattern)
+	}
+
+	if len(sv.Allowed) > 0 {
+		for _, allowed := range sv.Allowed {
+			if value == allowed {
+				return nil
+			}
+		}
+		return fmt.Errorf("%q is not one of %s", value, strings.Join(sv.Allowed, ", "))
+	}
+
+	return nil
+}
+
+// Schema defines the rules environment of the command has to match.
+// Variables are sorted by names.
+type Schema struct {
+	Variables []SchemaVariable
+}
+
+// schemaVariableDefinition is a definition of SchemaVariable in schema
+// file.
+type schemaVariableDefinition struct {
+	Required bool     `yaml:"required"`
+	Type     string   `yaml:"type"`
+	Regex    string   `yaml:"regex"`
+	Allowed  []string `yaml:"allowed"`
+	Default  *string  `yaml:"default"`
+}
+
+// parseSchema parses YAML schema file. Schema file is a mapping of
+// variable names to their rules: required, type, regex, allowed (list of
+// allowed values) and default. Defaults have to match the rules too.
+func parseSchema(content []byte) (schema Schema, err error) {
+	definitions := make(map[string]schemaVariableDefinition)
+	if err = yaml.Unmarshal(content, &definitions); err != nil {
+		return
+	}
+
+	names := make([]string, 0, len(definitions))
+	for name := range definitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		definition := definitions[name]
+		variable := SchemaVariable{
+			Name:     name,
+			Required: definition.Required,
+			Allowed:  definition.Allowed,
+		}
+
+		if variable.Type, err = parseVariableType(definition.Type); err != nil {
+			return schema, fmt.Errorf("Variable %s: %v", name, err)
+		}
+
+		if definition.Regex != "" {
+			if variable.Pattern, err = regexp.Compile(definition.Regex); err != nil {
+				return schema, fmt.Errorf("Variable %s: %v", name, err)
+			}
+		}
+
+		if definition.Default != nil {
+			variable.Default = *definition.Default
+			variable.HasDefault = true
+			if err = variable.Check(variable.Default); err != nil {
+				return schema, fmt.Errorf("Variable %s: incorrect default: %v", name, err)
+			}
+		}
+
+		schema.Variables = append(schema.Variables, variable)
+	}
+
+	return
+}
+
+// WithSchema sets the schema environment of the command has to match.
+// Schema is read from the YAML file on the given path. Empty path means
+// no schema.
+func WithSchema(path string) Option {
+	return func(options *Options) error {
+		if path == "" {
+			return nil
+		}
+
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		schema, err := parseSchema(content)
+		if err != nil {
+			return fmt.Errorf("Incorrect schema %s: %v", path, err)
+		}
+		options.Schema = schema
+
+		return nil
+	}
+}
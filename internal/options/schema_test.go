@@ -0,0 +1,102 @@
+package options
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	assert "github.com/stretchr/testify/assert"
+)
+
+const testSchema = `
+PORT:
+  type: int
+  default: 8080
+DATABASE_URL:
+  required: true
+  type: url
+LOG_LEVEL:
+  allowed: [debug, info]
+NAME:
+  regex: "^[a-z]+$"
+`
+
+func TestVariableTypeString(t *testing.T) {
+	assert.Equal(t, VariableTypeString.String(), "string")
+	assert.Equal(t, VariableTypeInt.String(), "int")
+	assert.Equal(t, VariableTypeBool.String(), "bool")
+	assert.Equal(t, VariableTypeURL.String(), "url")
+	assert.Equal(t, VariableTypeDuration.String(), "duration")
+}
+
+func TestParseVariableType(t *testing.T) {
+	for _, name := range []string{"", "string", "int", "BOOL", "url", "duration"} {
+		_, err := parseVariableType(name)
+		assert.Nil(t, err)
+	}
+
+	_, err := parseVariableType("float")
+	assert.NotNil(t, err)
+}
+
+func TestVariableTypeCheck(t *testing.T) {
+	assert.Nil(t, VariableTypeString.Check("anything"))
+	assert.Nil(t, VariableTypeInt.Check("-10"))
+	assert.NotNil(t, VariableTypeInt.Check("10s"))
+	assert.Nil(t, VariableTypeBool.Check("true"))
+	assert.NotNil(t, VariableTypeBool.Check("yes please"))
+	assert.Nil(t, VariableTypeURL.Check("postgres://localhost/db"))
+	assert.NotNil(t, VariableTypeURL.Check("localhost"))
+	assert.Nil(t, VariableTypeDuration.Check("1m30s"))
+	assert.NotNil(t, VariableTypeDuration.Check("10"))
+}
+
+func TestParseSchema(t *testing.T) {
+	schema, err := parseSchema([]byte(testSchema))
+	assert.Nil(t, err)
+	assert.Len(t, schema.Variables, 4)
+
+	names := make([]string, 0, len(schema.Variables))
+	for _, variable := range schema.Variables {
+		names = append(names, variable.Name)
+	}
+	assert.Equal(t, names, []string{"DATABASE_URL", "LOG_LEVEL", "NAME", "PORT"})
+
+	assert.True(t, schema.Variables[0].Required)
+	assert.Equal(t, schema.Variables[0].Type, VariableTypeURL)
+	assert.Equal(t, schema.Variables[1].Allowed, []string{"debug", "info"})
+	assert.Nil(t, schema.Variables[2].Check("name"))
+	assert.NotNil(t, schema.Variables[2].Check("Name"))
+	assert.True(t, schema.Variables[3].HasDefault)
+	assert.Equal(t, schema.Variables[3].Default, "8080")
+}
+
+func TestParseSchemaFail(t *testing.T) {
+	_, err := parseSchema([]byte("PORT:\n  type: float\n"))
+	assert.NotNil(t, err)
+
+	_, err = parseSchema([]byte("NAME:\n  regex: \"[\"\n"))
+	assert.NotNil(t, err)
+
+	_, err = parseSchema([]byte("PORT:\n  type: int\n  default: http\n"))
+	assert.NotNil(t, err)
+
+	_, err = parseSchema([]byte("- PORT\n"))
+	assert.NotNil(t, err)
+}
+
+func TestWithSchema(t *testing.T) {
+	fd, _ := ioutil.TempFile("", "")
+	fd.WriteString(testSchema)
+	fd.Close()
+	defer os.Remove(fd.Name())
+
+	options := &Options{}
+	assert.Nil(t, WithSchema("")(options))
+	assert.Len(t, options.Schema.Variables, 0)
+
+	assert.Nil(t, WithSchema(fd.Name())(options))
+	assert.Len(t, options.Schema.Variables, 4)
+
+	assert.NotNil(t, WithSchema(fd.Name()+"nothing")(options))
+}
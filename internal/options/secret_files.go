@@ -0,0 +1,27 @@
+// Package options defines common options set for the guide-dog app.
+package options
+
+// SecretFileSuffix is a suffix of variables which point to files with
+// the values of variables without suffix (FOO_FILE=/run/secrets/foo sets
+// FOO to the content of /run/secrets/foo).
+const SecretFileSuffix = "_FILE"
+
+// SecretFiles defines if *_FILE variables have to be resolved into the
+// contents of the files they point to. If DropFileVariables is set, *_FILE
+// variables themselves are removed after resolving.
+type SecretFiles struct {
+	Enabled           bool
+	DropFileVariables bool
+}
+
+// WithSecretFiles sets the rules of *_FILE secret indirection.
+func WithSecretFiles(enabled bool, dropFileVariables bool) Option {
+	return func(options *Options) error {
+		options.SecretFiles = SecretFiles{
+			Enabled:           enabled,
+			DropFileVariables: dropFileVariables,
+		}
+
+		return nil
+	}
+}
@@ -1,7 +1,15 @@
 // Package options defines common options set for the guide-dog app.
 package options
 
-import "strings"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// restartPolicySeparators separate name of the restart policy from its
+// argument like "on-failure:5" or "unless-exit-code=0,3-5".
+const restartPolicySeparators = ":="
 
 // SupervisorMode defines the mode of supervisor has to operate.
 // Please check SupervisorMode* constants family for the possible
@@ -9,21 +17,52 @@ import "strings"
 type SupervisorMode uint8
 
 // SupervisorMode* consts family defines possible work modes of the supervisor,
-// supported by the guide-dog.
+// supported by the guide-dog. SupervisorModeRestarting may be combined with
+// one of the restart policies (other values) which define what to do when
+// command exits: SupervisorModeNone never restarts it,
+// SupervisorModeAlways always does, SupervisorModeOnFailure restarts if
+// command failed, SupervisorModeUnlessExitCode restarts unless exit status
+// matches the given ones and SupervisorModeOnExitCode restarts only if it
+// does.
 const (
-	SupervisorModeNone   SupervisorMode = 0
-	SupervisorModeSimple SupervisorMode = 1 << iota
-	SupervisorModeRestarting
+	SupervisorModeNone       SupervisorMode = 0
+	SupervisorModeRestarting SupervisorMode = 1 << iota
+	SupervisorModeAlways
+	SupervisorModeOnFailure
+	SupervisorModeUnlessExitCode
+	SupervisorModeOnExitCode
 )
 
+// supervisorModePolicies is a mask of restart policies.
+const supervisorModePolicies = SupervisorModeAlways |
+	SupervisorModeOnFailure |
+	SupervisorModeUnlessExitCode |
+	SupervisorModeOnExitCode
+
+// RestartPolicy defines arguments of the restart policies. MaxRetries
+// limits the number of restarts of SupervisorModeOnFailure, zero means no
+// limit. ExitStatuses are matched by SupervisorModeUnlessExitCode and
+// SupervisorModeOnExitCode.
+type RestartPolicy struct {
+	MaxRetries   int
+	ExitStatuses ExitStatusSet
+}
+
 func (sm SupervisorMode) String() string {
 	mode := make([]string, 0, 2)
 
 	if sm == SupervisorModeNone {
 		mode = append(mode, "none")
 	} else {
-		if sm&SupervisorModeSimple > 0 {
-			mode = append(mode, "simple")
+		switch sm.Policy() {
+		case SupervisorModeAlways:
+			mode = append(mode, "always")
+		case SupervisorModeOnFailure:
+			mode = append(mode, "on-failure")
+		case SupervisorModeUnlessExitCode:
+			mode = append(mode, "unless-exit-code")
+		case SupervisorModeOnExitCode:
+			mode = append(mode, "on-exit-code")
 		}
 		if sm&SupervisorModeRestarting > 0 {
 			mode = append(mode, "restarting")
@@ -32,3 +71,101 @@ func (sm SupervisorMode) String() string {
 
 	return strings.Join(mode, " / ")
 }
+
+// Policy returns the restart policy of the mode without other flags.
+func (sm SupervisorMode) Policy() SupervisorMode {
+	return sm & supervisorModePolicies
+}
+
+// ShouldRestart tells if command has to be restarted after exit with the
+// given status. restarts is the number of restarts done by the policy
+// before.
+func (sm SupervisorMode) ShouldRestart(policy RestartPolicy, status ExitStatus, restarts int) bool {
+	switch sm.Policy() {
+	case SupervisorModeAlways:
+		return true
+	case SupervisorModeOnFailure:
+		return status.Failed() && (policy.MaxRetries == 0 || restarts < policy.MaxRetries)
+	case SupervisorModeUnlessExitCode:
+		return !policy.ExitStatuses.Matches(status)
+	case SupervisorModeOnExitCode:
+		return policy.ExitStatuses.Matches(status)
+	}
+
+	return false
+}
+
+func parseSupervisorMode(name string) (SupervisorMode, error) {
+	switch strings.ToLower(name) {
+	case "no", "none":
+		return SupervisorModeNone, nil
+	case "always":
+		return SupervisorModeAlways, nil
+	case "on-failure":
+		return SupervisorModeOnFailure, nil
+	case "unless-exit-code":
+		return SupervisorModeUnlessExitCode, nil
+	case "on-exit-code":
+		return SupervisorModeOnExitCode, nil
+	}
+
+	return SupervisorModeNone, fmt.Errorf("Unknown restart policy %s", name)
+}
+
+// parseRestartPolicy parses restart policy definitions like "no",
+// "always", "on-failure", "on-failure:5", "unless-exit-code=0,3-5" or
+// "on-exit-code=SIGKILL".
+func parseRestartPolicy(definition string) (mode SupervisorMode, policy RestartPolicy, err error) {
+	name, argument := definition, ""
+	if idx := strings.IndexAny(definition, restartPolicySeparators); idx >= 0 {
+		name, argument = definition[:idx], definition[idx+1:]
+	}
+
+	if mode, err = parseSupervisorMode(name); err != nil {
+		return
+	}
+
+	switch mode {
+	case SupervisorModeOnFailure:
+		if argument != "" {
+			policy.MaxRetries, err = strconv.Atoi(argument)
+			if err != nil || policy.MaxRetries < 0 {
+				err = fmt.Errorf("Incorrect number of retries in restart policy %s", definition)
+			}
+		}
+	case SupervisorModeUnlessExitCode, SupervisorModeOnExitCode:
+		if argument == "" {
+			err = fmt.Errorf("Exit statuses are required in restart policy %s", definition)
+		} else {
+			policy.ExitStatuses, err = parseExitStatusSet(argument)
+		}
+	default:
+		if argument != "" {
+			err = fmt.Errorf("Restart policy %s has no arguments", name)
+		}
+	}
+
+	return
+}
+
+// WithRestartPolicy sets the restart policy of supervised command. Empty
+// definition keeps the policy set by supervise option of NewOptions.
+func WithRestartPolicy(definition string) Option {
+	return func(options *Options) error {
+		if definition == "" {
+			return nil
+		}
+		if len(options.ExitCodes) > 0 {
+			return fmt.Errorf("Exit codes cannot be combined with restart policy, use unless-exit-code policy instead")
+		}
+
+		mode, policy, err := parseRestartPolicy(definition)
+		if err != nil {
+			return err
+		}
+		options.Supervisor = mode | options.Supervisor&SupervisorModeRestarting
+		options.RestartPolicy = policy
+
+		return nil
+	}
+}
@@ -2,6 +2,7 @@ package options
 
 import (
 	"strings"
+	"syscall"
 	"testing"
 
 	assert "github.com/stretchr/testify/assert"
@@ -9,11 +10,122 @@ import (
 
 func TestSupervisorModeName(t *testing.T) {
 	assert.Equal(t, "none", SupervisorModeNone.String())
-	assert.Equal(t, "simple", SupervisorModeSimple.String())
+	assert.Equal(t, "always", SupervisorModeAlways.String())
+	assert.Equal(t, "on-failure", SupervisorModeOnFailure.String())
+	assert.Equal(t, "unless-exit-code", SupervisorModeUnlessExitCode.String())
+	assert.Equal(t, "on-exit-code", SupervisorModeOnExitCode.String())
 	assert.Equal(t, "restarting", SupervisorModeRestarting.String())
 
-	mode := SupervisorModeSimple | SupervisorModeRestarting
-	assert.True(t, strings.Contains(mode.String(), "simple"))
+	mode := SupervisorModeAlways | SupervisorModeRestarting
+	assert.True(t, strings.Contains(mode.String(), "always"))
 	assert.True(t, strings.Contains(mode.String(), "restarting"))
 	assert.True(t, !strings.Contains(mode.String(), "none"))
 }
+
+func TestSupervisorModePolicy(t *testing.T) {
+	mode := SupervisorModeOnFailure | SupervisorModeRestarting
+	assert.Equal(t, SupervisorModeOnFailure, mode.Policy())
+	assert.Equal(t, SupervisorModeNone, SupervisorModeRestarting.Policy())
+}
+
+func TestSupervisorModeNoRestarts(t *testing.T) {
+	mode := SupervisorModeNone | SupervisorModeRestarting
+	assert.False(t, mode.ShouldRestart(RestartPolicy{}, ExitStatus{Code: 1}, 0))
+	assert.False(t, mode.ShouldRestart(RestartPolicy{}, ExitStatus{Code: 0}, 0))
+}
+
+func TestSupervisorModeAlwaysRestarts(t *testing.T) {
+	assert.True(t, SupervisorModeAlways.ShouldRestart(RestartPolicy{}, ExitStatus{Code: 0}, 100))
+	assert.True(t, SupervisorModeAlways.ShouldRestart(RestartPolicy{},
+		ExitStatus{Code: 130, Signal: syscall.SIGKILL}, 0))
+}
+
+func TestSupervisorModeOnFailure(t *testing.T) {
+	mode := SupervisorModeOnFailure
+	assert.False(t, mode.ShouldRestart(RestartPolicy{}, ExitStatus{Code: 0}, 0))
+	assert.True(t, mode.ShouldRestart(RestartPolicy{}, ExitStatus{Code: 1}, 100))
+	assert.True(t, mode.ShouldRestart(RestartPolicy{},
+		ExitStatus{Code: 130, Signal: syscall.SIGTERM}, 0))
+
+	policy := RestartPolicy{MaxRetries: 2}
+	assert.True(t, mode.ShouldRestart(policy, ExitStatus{Code: 1}, 1))
+	assert.False(t, mode.ShouldRestart(policy, ExitStatus{Code: 1}, 2))
+}
+
+func TestSupervisorModeUnlessExitCode(t *testing.T) {
+	policy := RestartPolicy{
+		ExitStatuses: ExitStatusSet{Codes: []ExitCodeRange{{From: 0, To: 0}}},
+	}
+	mode := SupervisorModeUnlessExitCode
+	assert.False(t, mode.ShouldRestart(policy, ExitStatus{Code: 0}, 0))
+	assert.True(t, mode.ShouldRestart(policy, ExitStatus{Code: 1}, 0))
+}
+
+func TestSupervisorModeOnExitCode(t *testing.T) {
+	policy := RestartPolicy{
+		ExitStatuses: ExitStatusSet{Signals: []syscall.Signal{syscall.SIGKILL}},
+	}
+	mode := SupervisorModeOnExitCode
+	assert.True(t, mode.ShouldRestart(policy, ExitStatus{Code: 130, Signal: syscall.SIGKILL}, 0))
+	assert.False(t, mode.ShouldRestart(policy, ExitStatus{Code: 130, Signal: syscall.SIGTERM}, 0))
+	assert.False(t, mode.ShouldRestart(policy, ExitStatus{Code: 1}, 0))
+}
+
+func TestParseRestartPolicy(t *testing.T) {
+	mode, policy, err := parseRestartPolicy("no")
+	assert.Nil(t, err)
+	assert.Equal(t, SupervisorModeNone, mode)
+
+	mode, policy, err = parseRestartPolicy("Always")
+	assert.Nil(t, err)
+	assert.Equal(t, SupervisorModeAlways, mode)
+
+	mode, policy, err = parseRestartPolicy("on-failure")
+	assert.Nil(t, err)
+	assert.Equal(t, SupervisorModeOnFailure, mode)
+	assert.Equal(t, 0, policy.MaxRetries)
+
+	mode, policy, err = parseRestartPolicy("on-failure:5")
+	assert.Nil(t, err)
+	assert.Equal(t, SupervisorModeOnFailure, mode)
+	assert.Equal(t, 5, policy.MaxRetries)
+
+	mode, policy, err = parseRestartPolicy("unless-exit-code=0,3-5,SIGTERM")
+	assert.Nil(t, err)
+	assert.Equal(t, SupervisorModeUnlessExitCode, mode)
+	assert.Equal(t, []ExitCodeRange{{From: 0, To: 0}, {From: 3, To: 5}}, policy.ExitStatuses.Codes)
+	assert.Equal(t, []syscall.Signal{syscall.SIGTERM}, policy.ExitStatuses.Signals)
+
+	mode, policy, err = parseRestartPolicy("on-exit-code=kill")
+	assert.Nil(t, err)
+	assert.Equal(t, SupervisorModeOnExitCode, mode)
+	assert.Equal(t, []syscall.Signal{syscall.SIGKILL}, policy.ExitStatuses.Signals)
+}
+
+func TestParseIncorrectRestartPolicy(t *testing.T) {
+	for _, definition := range []string{
+		"sometimes",
+		"always:5",
+		"on-failure:-1",
+		"on-failure:many",
+		"unless-exit-code",
+		"on-exit-code=",
+		"on-exit-code=SIGNOPE",
+	} {
+		_, _, err := parseRestartPolicy(definition)
+		assert.NotNil(t, err, definition)
+	}
+}
+
+func TestWithRestartPolicy(t *testing.T) {
+	options := &Options{Supervisor: SupervisorModeAlways | SupervisorModeRestarting}
+	assert.Nil(t, WithRestartPolicy("")(options))
+	assert.Equal(t, SupervisorModeAlways|SupervisorModeRestarting, options.Supervisor)
+
+	assert.Nil(t, WithRestartPolicy("on-failure:3")(options))
+	assert.Equal(t, SupervisorModeOnFailure|SupervisorModeRestarting, options.Supervisor)
+	assert.Equal(t, 3, options.RestartPolicy.MaxRetries)
+
+	options.ExitCodes = map[int]bool{1: true}
+	assert.NotNil(t, WithRestartPolicy("always")(options))
+}
@@ -0,0 +1,110 @@
+// Package options defines common options set for the guide-dog app.
+package options
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultArraySeparator is a separator which is used to join array
+// values by default.
+const DefaultArraySeparator = ","
+
+// NullMode defines what to do with null values in configs. Please check
+// NullMode* constants family for the possible values.
+type NullMode uint8
+
+// NullMode* consts family defines possible ways to treat null values.
+const (
+	NullModeUnset NullMode = iota
+	NullModeEmpty
+)
+
+func (nm NullMode) String() string {
+	switch nm {
+	case NullModeUnset:
+		return "unset"
+	case NullModeEmpty:
+		return "empty"
+	default:
+		return "ERROR"
+	}
+}
+
+func parseNullMode(name string) (mode NullMode, err error) {
+	switch strings.ToLower(name) {
+	case "unset":
+		mode = NullModeUnset
+	case "empty":
+		mode = NullModeEmpty
+	default:
+		err = fmt.Errorf("Unknown null mode %s", name)
+	}
+
+	return
+}
+
+// ArrayMode defines how to convert arrays from configs into strings.
+// Please check ArrayMode* constants family for the possible values.
+type ArrayMode uint8
+
+// ArrayMode* consts family defines possible ways to convert arrays.
+const (
+	ArrayModeJoin ArrayMode = iota
+	ArrayModeJSON
+)
+
+func (am ArrayMode) String() string {
+	switch am {
+	case ArrayModeJoin:
+		return "join"
+	case ArrayModeJSON:
+		return "json"
+	default:
+		return "ERROR"
+	}
+}
+
+func parseArrayMode(name string) (mode ArrayMode, err error) {
+	switch strings.ToLower(name) {
+	case "join":
+		mode = ArrayModeJoin
+	case "json":
+		mode = ArrayModeJSON
+	default:
+		err = fmt.Errorf("Unknown array mode %s", name)
+	}
+
+	return
+}
+
+// ValueConversion defines rules of conversion of non-string values from
+// structured configs (JSON, YAML, TOML).
+type ValueConversion struct {
+	Null           NullMode
+	Array          ArrayMode
+	ArraySeparator string
+}
+
+// WithValueConversion sets the rules for null and array values.
+func WithValueConversion(nullMode string, arrayMode string, arraySeparator string) Option {
+	return func(options *Options) (err error) {
+		convertedNullMode, err := parseNullMode(nullMode)
+		if err != nil {
+			return
+		}
+
+		convertedArrayMode, err := parseArrayMode(arrayMode)
+		if err != nil {
+			return
+		}
+
+		options.Values = ValueConversion{
+			Null:           convertedNullMode,
+			Array:          convertedArrayMode,
+			ArraySeparator: arraySeparator,
+		}
+
+		return
+	}
+}
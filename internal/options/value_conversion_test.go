@@ -0,0 +1,86 @@
+package options
+
+import (
+	"strings"
+	"testing"
+
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestParseNullMode(t *testing.T) {
+	validNames := []string{"unset", "empty"}
+	modes := []NullMode{NullModeUnset, NullModeEmpty}
+
+	for idx, name := range validNames {
+		for _, caseSensitiveName := range []string{name, strings.ToUpper(name)} {
+			mode, err := parseNullMode(caseSensitiveName)
+			assert.Nil(t, err)
+			assert.Equal(t, modes[idx], mode)
+		}
+	}
+
+	_, err := parseNullMode("WTF")
+	assert.NotNil(t, err)
+}
+
+func TestParseArrayMode(t *testing.T) {
+	validNames := []string{"join", "json"}
+	modes := []ArrayMode{ArrayModeJoin, ArrayModeJSON}
+
+	for idx, name := range validNames {
+		for _, caseSensitiveName := range []string{name, strings.ToUpper(name)} {
+			mode, err := parseArrayMode(caseSensitiveName)
+			assert.Nil(t, err)
+			assert.Equal(t, modes[idx], mode)
+		}
+	}
+
+	_, err := parseArrayMode("WTF")
+	assert.NotNil(t, err)
+}
+
+func TestValueConversionNames(t *testing.T) {
+	assert.Equal(t, NullModeUnset.String(), "unset")
+	assert.Equal(t, NullModeEmpty.String(), "empty")
+	assert.Equal(t, ArrayModeJoin.String(), "join")
+	assert.Equal(t, ArrayModeJSON.String(), "json")
+}
+
+func TestWithValueConversion(t *testing.T) {
+	options, err := NewOptions("term", // signal
+		[]string{}, // envs
+		0,          // gracefulTimeout
+		"json",     // configFormat
+		[]string{}, // configPaths
+		[]string{}, // pathsToTracks
+		"",         // lockFile
+		false,      // pty
+		false,      // supervise
+		false,      // restartOnConfigChanges
+		[]string{}, // exitCodes
+		WithValueConversion("empty", "json", ":"))
+
+	assert.Nil(t, err)
+	assert.Equal(t, options.Values.Null, NullModeEmpty)
+	assert.Equal(t, options.Values.Array, ArrayModeJSON)
+	assert.Equal(t, options.Values.ArraySeparator, ":")
+}
+
+func TestDefaultValueConversion(t *testing.T) {
+	options, err := NewOptions("term", // signal
+		[]string{}, // envs
+		0,          // gracefulTimeout
+		"json",     // configFormat
+		[]string{}, // configPaths
+		[]string{}, // pathsToTracks
+		"",         // lockFile
+		false,      // pty
+		false,      // supervise
+		false,      // restartOnConfigChanges
+		[]string{}) // exitCodes
+
+	assert.Nil(t, err)
+	assert.Equal(t, options.Values.Null, NullModeUnset)
+	assert.Equal(t, options.Values.Array, ArrayModeJoin)
+	assert.Equal(t, options.Values.ArraySeparator, DefaultArraySeparator)
+}
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"fmt"
 	"os"
+	"strconv"
 	"strings"
 
 	log "github.com/Sirupsen/logrus"
@@ -19,6 +21,8 @@ const (
 	version = "0.1"
 
 	envDirExitCode = 111
+	schemaExitCode = 78
+	usageExitCode  = 64
 )
 
 var (
@@ -43,13 +47,84 @@ var (
 			Default("5s").
 			Duration()
 	configFormat = cmdLine.
-			Flag("config-format", "Format of configs.").
+			Flag("config-format", "Format of configs. If not set, format is detected by file extension or content.").
 			Short('c').
-			Enum("", "none", "json", "yaml", "ini", "envdir")
+			Enum("", "none", "json", "yaml", "ini", "envdir", "dotenv", "toml", "properties")
 	configPath = cmdLine.
-			Flag("config-path", "Config path.").
+			Flag("config-path", "Config path, optionally prefixed by format like 'yaml:/etc/base.yaml'. Commands printing configs are set like 'json+exec:vault-env --json' or 'exec:./metadata.sh' for KEY=VALUE lines. There may be several options, later configs override earlier ones.").
 			Short('f').
+			Strings()
+	execInterval = cmdLine.
+			Flag("config-exec-interval", "How often to re-run commands of config sources. Changed environment triggers the same restart as changed configs. Zero disables refreshing.").
+			Default("0s").
+			Duration()
+	envDirMode = cmdLine.
+			Flag("envdir-mode", "How to read envdirs: like envdir of daemontools (first line only, empty files remove variables) or in old compatible way (whole trimmed files).").
+			Default("daemontools").
+			Enum("daemontools", "compat")
+	envDirRecursive = cmdLine.
+			Flag("envdir-recursive", "Read nested directories of envdirs, names are composed from paths (envdir/DB/HOST is DB_HOST).").
+			Bool()
+	nameSeparator = cmdLine.
+			Flag("name-separator", "Separator for names of variables composed from nested config structures.").
+			Default(options.DefaultNameSeparator).
+			String()
+	nameCase = cmdLine.
+			Flag("name-case", "Case of names of variables composed from nested config structures.").
+			Default("upper").
+			Enum("keep", "upper", "lower")
+	nullValues = cmdLine.
+			Flag("null-values", "What to do with null values in configs: unset variable or set it to empty string.").
+			Default("unset").
+			Enum("unset", "empty")
+	arrayValues = cmdLine.
+			Flag("array-values", "How to convert arrays from configs: join items or encode them as JSON.").
+			Default("join").
+			Enum("join", "json")
+	arraySeparator = cmdLine.
+			Flag("array-separator", "Separator for joined array items.").
+			Default(options.DefaultArraySeparator).
+			String()
+	iniMode = cmdLine.
+		Flag("ini-mode", "How to handle sections of INI configs: merge all, prefix names with section names or select only given sections.").
+		Default("merge").
+		Enum("merge", "prefix", "select")
+	iniSections = cmdLine.
+			Flag("ini-section", "Section of INI config to load on top of the global one in select mode. There may be several options.").
+			Strings()
+	propertiesEnvNames = cmdLine.
+				Flag("properties-env-names", "Convert keys of Java properties files like 'db.host' into names like 'DB_HOST' according to name separator and case.").
+				Bool()
+	interpolate = cmdLine.
+			Flag("interpolate", "Expand ${VAR} and ${VAR:-default} references in config values.").
+			Bool()
+	cleanEnv = cmdLine.
+			Flag("clean-env", "Start command with an empty environment: only variables from configs and --env are set.").
+			Bool()
+	inherit = cmdLine.
+		Flag("inherit", "Glob pattern of environment variables to inherit, others are dropped. There may be several options '--inherit PATH --inherit LC_*'.").
+		Strings()
+	noInherit = cmdLine.
+			Flag("no-inherit", "Glob pattern of environment variables not to inherit. There may be several options.").
+			Strings()
+	secretFiles = cmdLine.
+			Flag("secret-files", "Set FOO to the content of the file FOO_FILE points to. Inherited FOO_FILE variables are not resolved.").
+			Bool()
+	dropSecretFileVars = cmdLine.
+				Flag("drop-secret-file-vars", "Remove FOO_FILE variables after they are resolved. Works only if 'secret-files' option is enabled.").
+				Bool()
+	decryptionKey = cmdLine.
+			Flag("decryption-key", "Key file to decrypt encrypted configs ('yaml+enc:/etc/secrets.yaml' or '*.enc' files).").
 			String()
+	schema = cmdLine.
+		Flag("schema", "YAML schema of environment: required variables, types, regexes, allowed values and defaults.").
+		String()
+	strictReload = cmdLine.
+			Flag("strict-reload", "If environment cannot be reloaded, keep the current one and do not restart the command.").
+			Bool()
+	reloadFailureHook = cmdLine.
+				Flag("reload-failure-hook", "Shell command to run if environment cannot be reloaded. Error is passed in GUIDEDOG_RELOAD_ERROR environment variable.").
+				String()
 	pathsToTrack = cmdLine.
 			Flag("path-to-track", "Paths to track.").
 			Short('p').
@@ -67,20 +142,85 @@ var (
 			Short('x').
 			Bool()
 	supervise = cmdLine.
-			Flag("supervise", "Set if it is required to supervise command. By default no supervising is performed.").
+			Flag("supervise", "Set if it is required to supervise command: restart it unless it exits with one of 'exit-on-code' codes. By default no supervising is performed.").
 			Short('s').
 			Bool()
 	superviseRestartOnConfigPathChanges = cmdLine.
 						Flag("restart-on-config-changes", "Do the restart of the process if config is changed. Works only if 'supervise' option is enabled.").
 						Short('r').
 						Bool()
+	restartPolicy = cmdLine.
+			Flag("restart", "Restart policy of supervised command: 'no', 'always', 'on-failure[:max-retries]', 'unless-exit-code=CODES' or 'on-exit-code=CODES'. CODES are comma-separated exit codes, ranges and signal names like '0,3-5,SIGKILL'. Overrides 'supervise' option.").
+			String()
+	restartDelay = cmdLine.
+			Flag("restart-delay", "Delay before restart after failed start. It is doubled for each consecutive failed start.").
+			Default(options.DefaultRestartDelay.String()).
+			Duration()
+	restartMaxDelay = cmdLine.
+			Flag("restart-max-delay", "Maximal delay before restart after failed start.").
+			Default(options.DefaultRestartMaxDelay.String()).
+			Duration()
+	restartJitter = cmdLine.
+			Flag("restart-jitter", "Randomization of restart delay in percents.").
+			Default(strconv.Itoa(options.DefaultRestartJitter)).
+			Int()
+	startSeconds = cmdLine.
+			Flag("start-seconds", "Run of the command shorter than that is a failed start.").
+			Default(options.DefaultStartTime.String()).
+			Duration()
+	maxFailedStarts = cmdLine.
+			Flag("max-failed-starts", "Exit if there are that many failed starts within 'failed-starts-window'. Zero means no limit.").
+			Default("0").
+			Int()
+	failedStartsWindow = cmdLine.
+				Flag("failed-starts-window", "Time window to count failed starts in.").
+				Default(options.DefaultFailedStartsWindow.String()).
+				Duration()
+	healthChecks = cmdLine.
+			Flag("health-check", "Health check of running command: shell command like 'cmd:pg_isready', HTTP GET like 'http://127.0.0.1:8080/health' or TCP connect like 'tcp:127.0.0.1:5432'. Unhealthy command is restarted. There may be several options.").
+			Strings()
+	healthCheckInterval = cmdLine.
+				Flag("health-interval", "Time between health checks.").
+				Default(options.DefaultHealthCheckInterval.String()).
+				Duration()
+	healthCheckTimeout = cmdLine.
+				Flag("health-timeout", "Time health check has to succeed within.").
+				Default(options.DefaultHealthCheckTimeout.String()).
+				Duration()
+	healthCheckRetries = cmdLine.
+				Flag("health-retries", "Restart command after that many consecutive failed health checks.").
+				Default(strconv.Itoa(options.DefaultHealthCheckRetries)).
+				Int()
+	healthCheckStartPeriod = cmdLine.
+				Flag("health-start-period", "Failed health checks within that time after the start of command are not counted.").
+				Default("0s").
+				Duration()
+	notify = cmdLine.
+		Flag("notify", "Give command its own NOTIFY_SOCKET and report readiness to systemd only after command sends READY=1. Without it READY=1 is sent right after the start of command.").
+		Bool()
+	watchdogTimeout = cmdLine.
+			Flag("watchdog-timeout", "Restart command if it does not send WATCHDOG=1 to its notify socket within that time. Works only if 'notify' option is enabled. Zero disables watchdog.").
+			Default("0s").
+			Duration()
 	exitOnCodes = cmdLine.
 			Flag("exit-on-code", "Exit if executed command finished with given code. You may define this option several times if you want to have several codes.").
 			Short('o').
 			Strings()
+	printEnv = cmdLine.
+			Flag("print-env", "Print resolved environment instead of executing the command.").
+			Bool()
+	printFormat = cmdLine.
+			Flag("print-format", "Format of environment printed by 'print-env' option: shell export lines, JSON, Docker env-file, systemd EnvironmentFile or envdir.").
+			Default("export").
+			Enum("export", "json", "docker", "systemd", "envdir")
+	printDir = cmdLine.
+			Flag("print-dir", "Output directory for envdir format of 'print-env' option.").
+			String()
+	printOrigins = cmdLine.
+			Flag("print-origins", "Print where values printed by 'print-env' option come from.").
+			Bool()
 	commandToExecute = cmdLine.
 				Arg("command", "Command which has to be executed.").
-				Required().
 				Strings()
 )
 
@@ -112,6 +252,15 @@ func init() {
 func mainWithExitCode() int {
 	kingpin.MustParse(cmdLine.Parse(os.Args[1:]))
 
+	switch {
+	case *printEnv && len(*commandToExecute) > 0:
+		fmt.Fprintln(os.Stderr, "Command cannot be executed with print-env option.")
+		return usageExitCode
+	case !*printEnv && len(*commandToExecute) == 0:
+		fmt.Fprintln(os.Stderr, "Command to execute is required.")
+		return usageExitCode
+	}
+
 	if os.Getenv(profileEnvVariable) != "" {
 		defer profile.Start(profile.CPUProfile).Stop()
 	}
@@ -129,16 +278,44 @@ func mainWithExitCode() int {
 		*pty,
 		*supervise,
 		*superviseRestartOnConfigPathChanges,
-		*exitOnCodes)
+		*exitOnCodes,
+		options.WithEnvDir(*envDirMode, *envDirRecursive),
+		options.WithNaming(*nameSeparator, *nameCase),
+		options.WithValueConversion(*nullValues, *arrayValues, *arraySeparator),
+		options.WithINI(*iniMode, *iniSections),
+		options.WithProperties(*propertiesEnvNames),
+		options.WithInterpolation(*interpolate),
+		options.WithInheritance(*cleanEnv, *inherit, *noInherit),
+		options.WithSecretFiles(*secretFiles, *dropSecretFileVars),
+		options.WithDecryptionKey(*decryptionKey),
+		options.WithSchema(*schema),
+		options.WithExecInterval(*execInterval),
+		options.WithReload(*strictReload, *reloadFailureHook),
+		options.WithPrint(*printFormat, *printDir, *printOrigins),
+		options.WithRestartPolicy(*restartPolicy),
+		options.WithHealthChecks(*healthChecks, *healthCheckInterval, *healthCheckTimeout,
+			*healthCheckRetries, *healthCheckStartPeriod),
+		options.WithNotify(*notify, *watchdogTimeout),
+		options.WithRestart(*restartDelay, *restartMaxDelay, *restartJitter,
+			*startSeconds, *maxFailedStarts, *failedStartsWindow))
 	if err != nil {
 		panic(err)
 	}
 
 	env, err := environment.NewEnvironment(parsedOptions)
-	if err != nil {
+	if _, ok := err.(*environment.ValidationError); ok {
+		fmt.Fprintln(os.Stderr, err)
+		return schemaExitCode
+	} else if err != nil {
 		panic(err)
 	}
-	log.WithField("environment", env).Info("Environment.")
+
+	if *printEnv {
+		if err = env.Print(os.Stdout); err != nil {
+			panic(err)
+		}
+		return 0
+	}
 
 	if *runInShell {
 		shell := os.Getenv("SHELL")
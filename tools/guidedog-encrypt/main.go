@@ -0,0 +1,65 @@
+// guidedog-encrypt encrypts configs so guide-dog can decrypt them with
+// the same key file ('--decryption-key' option).
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	kingpin "gopkg.in/alecthomas/kingpin.v1"
+
+	"github.com/9seconds/guidedog/internal/environment"
+)
+
+var (
+	cmdLine = kingpin.New("guidedog-encrypt", "Encrypt config for guide-dog.")
+
+	keyFile = cmdLine.
+		Flag("key", "Key file: 32 bytes as is, hex or base64 encoded.").
+		Short('k').
+		Required().
+		String()
+	output = cmdLine.
+		Flag("output", "Where to write encrypted config. Stdout by default.").
+		Short('o').
+		String()
+	input = cmdLine.
+		Arg("config", "Config to encrypt.").
+		Required().
+		String()
+)
+
+func main() {
+	kingpin.MustParse(cmdLine.Parse(os.Args[1:]))
+
+	if err := encrypt(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// encrypt reads the config and writes it encrypted.
+func encrypt() error {
+	key, err := environment.ReadKeyFile(*keyFile)
+	if err != nil {
+		return err
+	}
+
+	plaintext, err := ioutil.ReadFile(*input)
+	if err != nil {
+		return err
+	}
+
+	encrypted, err := environment.EncryptConfig(key, plaintext)
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		_, err = os.Stdout.Write(encrypted)
+		return err
+	}
+
+	return ioutil.WriteFile(*output, encrypted, os.FileMode(0644))
+}